@@ -0,0 +1,306 @@
+package foundation
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ferr "github.com/foundation-go/foundation/errors"
+)
+
+// adminRedacted replaces the values of secret settings in the configuration dump.
+const adminRedacted = "[REDACTED]"
+
+// registerWorker makes the worker available for pausing through the admin API.
+func (s *Service) registerWorker(name string, worker *SpinWorker) {
+	if s.workers == nil {
+		s.workers = make(map[string]*SpinWorker)
+	}
+
+	s.workers[name] = worker
+}
+
+// adminHandler returns the handler of the admin API, served on the metrics server under `/admin/`.
+// All the requests must be authenticated with the `ADMIN_TOKEN` bearer token.
+func (s *Service) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/log-level", s.adminGetLogLevel)
+	mux.HandleFunc("PUT /admin/log-level", s.adminSetLogLevel)
+	mux.HandleFunc("GET /admin/workers", s.adminListWorkers)
+	mux.HandleFunc("POST /admin/workers/{name}/pause", s.adminPauseWorker)
+	mux.HandleFunc("POST /admin/workers/{name}/resume", s.adminResumeWorker)
+	mux.HandleFunc("GET /admin/config", s.adminGetConfig)
+	mux.HandleFunc("GET /admin/components", s.adminListComponents)
+	mux.HandleFunc("GET /admin/outbox/dead", s.adminListOutboxDeadEvents)
+	mux.HandleFunc("POST /admin/outbox/dead/{id}/requeue", s.adminRequeueOutboxDeadEvent)
+	mux.HandleFunc("DELETE /admin/outbox/dead/{id}", s.adminDiscardOutboxDeadEvent)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		expected := s.currentAdminToken()
+		if !ok || expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// currentAdminToken returns the `ADMIN_TOKEN`, as rotated, if it was.
+func (s *Service) currentAdminToken() string {
+	if token, ok := s.root().adminToken.Load().(string); ok {
+		return token
+	}
+
+	return s.Config.Admin.Token
+}
+
+// rotateAdminToken applies the rotated `ADMIN_TOKEN`. The admin API cannot be disabled at runtime, so
+// an empty token is rejected.
+func (s *Service) rotateAdminToken(value string) error {
+	if value == "" {
+		return errors.New("`ADMIN_TOKEN` cannot be emptied at runtime, restart the service to disable the admin API")
+	}
+
+	s.root().adminToken.Store(value)
+
+	return nil
+}
+
+func writeAdminResponse(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeAdminResponse(w, status, map[string]string{"error": message})
+}
+
+type adminLogLevel struct {
+	Level string `json:"level"`
+}
+
+func (s *Service) adminGetLogLevel(w http.ResponseWriter, _ *http.Request) {
+	writeAdminResponse(w, http.StatusOK, adminLogLevel{Level: logLevelName(s.root().logLevel.Level())})
+}
+
+func (s *Service) adminSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var body adminLogLevel
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	level, err := parseLogLevel(body.Level)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.root().logLevel.Set(level)
+	s.Logger.Warn(fmt.Sprintf("Log level changed to `%s` through the admin API", logLevelName(level)))
+
+	writeAdminResponse(w, http.StatusOK, adminLogLevel{Level: logLevelName(level)})
+}
+
+type adminWorker struct {
+	Name   string `json:"name"`
+	Paused bool   `json:"paused"`
+}
+
+func (s *Service) adminListWorkers(w http.ResponseWriter, _ *http.Request) {
+	workers := make([]adminWorker, 0, len(s.workers))
+	for name, worker := range s.workers {
+		workers = append(workers, adminWorker{Name: name, Paused: worker.Paused()})
+	}
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].Name < workers[j].Name
+	})
+
+	writeAdminResponse(w, http.StatusOK, workers)
+}
+
+func (s *Service) adminPauseWorker(w http.ResponseWriter, r *http.Request) {
+	s.adminToggleWorker(w, r, (*SpinWorker).Pause)
+}
+
+func (s *Service) adminResumeWorker(w http.ResponseWriter, r *http.Request) {
+	s.adminToggleWorker(w, r, (*SpinWorker).Resume)
+}
+
+func (s *Service) adminToggleWorker(w http.ResponseWriter, r *http.Request, toggle func(*SpinWorker)) {
+	name := r.PathValue("name")
+
+	worker, ok := s.workers[name]
+	if !ok {
+		writeAdminError(w, http.StatusNotFound, "worker not found: "+name)
+		return
+	}
+
+	toggle(worker)
+
+	writeAdminResponse(w, http.StatusOK, adminWorker{Name: name, Paused: worker.Paused()})
+}
+
+func (s *Service) adminGetConfig(w http.ResponseWriter, _ *http.Request) {
+	config, err := redactedConfig(s.Config)
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeAdminResponse(w, http.StatusOK, config)
+}
+
+func (s *Service) adminListComponents(w http.ResponseWriter, _ *http.Request) {
+	writeAdminResponse(w, http.StatusOK, s.checkHealth())
+}
+
+// adminOutboxDeadEvent is a dead outbox event in the admin API responses.
+type adminOutboxDeadEvent struct {
+	ID        int64             `json:"id"`
+	Topic     string            `json:"topic"`
+	Key       string            `json:"key"`
+	Headers   map[string]string `json:"headers"`
+	CreatedAt time.Time         `json:"created_at"`
+	Attempts  int32             `json:"attempts"`
+	LastError string            `json:"last_error"`
+	DeadAt    time.Time         `json:"dead_at"`
+}
+
+func (s *Service) adminListOutboxDeadEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			writeAdminError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	events, fErr := s.ListOutboxDeadEvents(r.Context(), int32(limit))
+	if fErr != nil {
+		writeAdminError(w, http.StatusInternalServerError, fErr.Error())
+		return
+	}
+
+	result := make([]adminOutboxDeadEvent, 0, len(events))
+	for _, event := range events {
+		headers := make(map[string]string)
+		_ = json.Unmarshal(event.Headers, &headers)
+
+		result = append(result, adminOutboxDeadEvent{
+			ID:        event.ID,
+			Topic:     event.Topic,
+			Key:       event.Key,
+			Headers:   headers,
+			CreatedAt: event.CreatedAt.Time,
+			Attempts:  event.Attempts,
+			LastError: event.LastError,
+			DeadAt:    event.DeadAt.Time,
+		})
+	}
+
+	writeAdminResponse(w, http.StatusOK, result)
+}
+
+func (s *Service) adminRequeueOutboxDeadEvent(w http.ResponseWriter, r *http.Request) {
+	s.adminHandleOutboxDeadEvent(w, r, s.RequeueOutboxDeadEvent)
+}
+
+func (s *Service) adminDiscardOutboxDeadEvent(w http.ResponseWriter, r *http.Request) {
+	s.adminHandleOutboxDeadEvent(w, r, s.DiscardOutboxDeadEvent)
+}
+
+func (s *Service) adminHandleOutboxDeadEvent(w http.ResponseWriter, r *http.Request, handle func(context.Context, int64) (bool, ferr.FoundationError)) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	found, fErr := handle(r.Context(), id)
+	if fErr != nil {
+		writeAdminError(w, http.StatusInternalServerError, fErr.Error())
+		return
+	}
+
+	if !found {
+		writeAdminError(w, http.StatusNotFound, "dead event not found")
+		return
+	}
+
+	writeAdminResponse(w, http.StatusOK, map[string]int64{"id": id})
+}
+
+// redactedConfig returns the configuration with the secrets redacted. The connection URLs are
+// stripped of their credentials only, so that the hosts remain visible, see `redactURL`.
+func redactedConfig(c *Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	redact := func(isURL bool, path ...string) {
+		node := result
+		for _, key := range path[:len(path)-1] {
+			next, ok := node[key].(map[string]interface{})
+			if !ok {
+				return
+			}
+			node = next
+		}
+
+		key := path[len(path)-1]
+		value, _ := node[key].(string)
+		if value == "" {
+			return
+		}
+
+		node[key] = adminRedacted
+		if isURL {
+			node[key] = redactURL(value)
+		}
+	}
+
+	redact(true, "Database", "URL")
+	redact(false, "Kafka", "SASL", "Password")
+	redact(false, "Sentry", "DSN")
+	redact(true, "Redis", "URL")
+	redact(true, "JobsEnqueuer", "URL")
+	redact(false, "Admin", "Token")
+	redact(true, "Hydra", "AdminURL")
+
+	return result, nil
+}
+
+// redactURL returns the connection URL without its password and query parameters, which may hold
+// credentials too (e.g. `?password=`). Values that are not URLs, such as the `key=value` PostgreSQL
+// connection strings, are redacted as a whole.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return adminRedacted
+	}
+
+	redacted := &url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: u.Path}
+
+	return redacted.Redacted()
+}
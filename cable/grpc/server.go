@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strconv"
 
 	pb "github.com/foundation-go/foundation/cable/grpc/proto"
 	"github.com/google/uuid"
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -51,7 +51,7 @@ type Server struct {
 	WithAuthentication bool
 	AuthenticationFunc AuthenticationFunc
 
-	Logger *logrus.Entry
+	Logger *slog.Logger
 }
 
 // ConnIdentifier is used to identify a specific connection through its AccessToken.
@@ -60,7 +60,7 @@ type ConnIdentifier struct {
 }
 
 func (s *Server) Connect(ctx context.Context, in *pb.ConnectionRequest) (*pb.ConnectionResponse, error) {
-	s.Logger.WithField("url", in.Env.Url).Debug("Connect received")
+	s.Logger.Debug("Connect received", "url", in.Env.Url)
 
 	unauthenticatedResp := &pb.ConnectionResponse{
 		Status:        pb.Status_FAILURE,
@@ -88,7 +88,7 @@ func (s *Server) Connect(ctx context.Context, in *pb.ConnectionRequest) (*pb.Con
 
 		userID, err := s.AuthenticationFunc(ctx, accessToken)
 		if err != nil {
-			s.Logger.WithError(err).Error("Authentication failed")
+			s.Logger.Error("Authentication failed", "error", err)
 
 			return unauthenticatedResp, nil
 		}
@@ -123,7 +123,7 @@ func (s *Server) Connect(ctx context.Context, in *pb.ConnectionRequest) (*pb.Con
 }
 
 func (s *Server) Command(ctx context.Context, in *pb.CommandMessage) (*pb.CommandResponse, error) {
-	s.Logger.WithField("command", in.Command).Debug("Command received")
+	s.Logger.Debug("Command received", "command", in.Command)
 
 	resp := &pb.CommandResponse{
 		Status: pb.Status_SUCCESS,
@@ -135,7 +135,7 @@ func (s *Server) Command(ctx context.Context, in *pb.CommandMessage) (*pb.Comman
 
 	ident, err := s.decodeIdentifier(in.Identifier)
 	if err != nil {
-		s.Logger.WithError(err).Error("Failed to parse identifier")
+		s.Logger.Error("Failed to parse identifier", "error", err)
 
 		return &pb.CommandResponse{
 			Status:   pb.Status_FAILURE,
@@ -154,7 +154,7 @@ func (s *Server) Command(ctx context.Context, in *pb.CommandMessage) (*pb.Comman
 
 		ch, err := s.channelFromIdent(ident)
 		if err != nil {
-			s.Logger.WithError(err).Error("Channel not found")
+			s.Logger.Error("Channel not found", "error", err)
 
 			return &pb.CommandResponse{
 				Status:        pb.Status_FAILURE,
@@ -164,7 +164,7 @@ func (s *Server) Command(ctx context.Context, in *pb.CommandMessage) (*pb.Comman
 		}
 
 		if err = ch.Authorize(ctx, in.Env.Cstate[UserIDKey], ident); err != nil {
-			s.Logger.WithError(err).Debug("Authorization failed")
+			s.Logger.Debug("Authorization failed", "error", err)
 
 			return &pb.CommandResponse{
 				Status:        pb.Status_FAILURE,
@@ -180,7 +180,7 @@ func (s *Server) Command(ctx context.Context, in *pb.CommandMessage) (*pb.Comman
 	case CmdUnsubscribe:
 		ch, err := s.channelFromIdent(ident)
 		if err != nil {
-			s.Logger.WithError(err).Error("Channel not found")
+			s.Logger.Error("Channel not found", "error", err)
 
 			return &pb.CommandResponse{
 				Status:   pb.Status_FAILURE,
@@ -192,7 +192,7 @@ func (s *Server) Command(ctx context.Context, in *pb.CommandMessage) (*pb.Comman
 
 		return resp, nil
 	default:
-		s.Logger.WithField("command", in.Command).Error("Unknown command")
+		s.Logger.Error("Unknown command", "command", in.Command)
 
 		return &pb.CommandResponse{
 			Status:   pb.Status_ERROR,
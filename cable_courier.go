@@ -3,13 +3,13 @@ package foundation
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	cablecourier "github.com/foundation-go/foundation/cable/courier"
 	ferr "github.com/foundation-go/foundation/errors"
 	ferrpb "github.com/foundation-go/foundation/errors/proto"
 	fkafka "github.com/foundation-go/foundation/kafka"
 	"github.com/getsentry/sentry-go"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -22,9 +22,16 @@ type CableCourier struct {
 }
 
 // InitCableCourier initializes a new CableCourier.
-func InitCableCourier(name string) *CableCourier {
+func InitCableCourier(name string, opts ...InitOption) *CableCourier {
 	return &CableCourier{
-		EventsWorker: InitEventsWorker(name),
+		EventsWorker: InitEventsWorker(name, opts...),
+	}
+}
+
+// NewCableCourier returns a cable courier mode running on the given service, see `Service.StartModes`.
+func NewCableCourier(s *Service) *CableCourier {
+	return &CableCourier{
+		EventsWorker: NewEventsWorker(s),
 	}
 }
 
@@ -39,7 +46,7 @@ type CableCourierResolvers map[proto.Message][]CableMessageResolver
 type CableMessageEventHandler struct {
 	// Resolver is used to resolve the stream name for a given event.
 	Resolver     CableMessageResolver
-	Logger       *logrus.Entry
+	Logger       *slog.Logger
 	Service      *Service
 	RedisChannel string
 }
@@ -81,7 +88,7 @@ func (opts *CableCourierOptions) EventHandlers(s *Service) map[proto.Message][]E
 		for _, resolver := range resolvers {
 			handlers[protoObj] = append(handlers[protoObj], &CableMessageEventHandler{
 				Resolver:     resolver,
-				Logger:       s.Logger.WithField("proto", ProtoToName(protoObj)),
+				Logger:       s.Logger.With("proto", ProtoToName(protoObj)),
 				Service:      s,
 				RedisChannel: opts.RedisChannel,
 			})
@@ -93,8 +100,15 @@ func (opts *CableCourierOptions) EventHandlers(s *Service) map[proto.Message][]E
 
 // Start runs a cable_courier worker using the given CableCourierOptions.
 func (c *CableCourier) Start(opts *CableCourierOptions) {
+	c.Service.Start(c.Mode(opts))
+}
+
+// Mode returns the cable courier mode to run with `Service.StartModes`.
+func (c *CableCourier) Mode(opts *CableCourierOptions) *StartOptions {
+	c.Options = opts
+
 	if opts != nil && opts.RedisChannel == "" {
-		opts.RedisChannel = GetEnvOrString("ANYCABLE_REDIS_CHANNEL", "__anycable__")
+		opts.RedisChannel = c.Config.Cable.RedisChannel
 	}
 
 	ewOpts := &EventsWorkerOptions{
@@ -105,7 +119,7 @@ func (c *CableCourier) Start(opts *CableCourierOptions) {
 		},
 	}
 
-	c.EventsWorker.Start(ewOpts)
+	return c.EventsWorker.Mode(ewOpts)
 }
 
 // Handle uses the associated CableMessageResolver to determine the appropriate stream
@@ -118,7 +132,7 @@ func (h *CableMessageEventHandler) Handle(ctx context.Context, event *Event, msg
 		// and capture it with Sentry.
 		err = fmt.Errorf("failed to get stream for event: %w", err)
 		sentry.CaptureException(err)
-		h.Logger.Error(err)
+		h.Logger.Error(err.Error())
 
 		return nil, nil
 	}
@@ -130,7 +144,15 @@ func (h *CableMessageEventHandler) Handle(ctx context.Context, event *Event, msg
 
 	// Broadcast the message to the stream.
 	// If the broadcast fails, we log the error, capture it with Sentry and go on to avoid infinite loops.
-	err = cablecourier.NewClient(h.Service.GetRedis(), h.RedisChannel).BroadcastMessage(
+	redisClient, fErr := h.Service.LookupRedis()
+	if fErr != nil {
+		sentry.CaptureException(fErr)
+		h.Logger.Error(fErr.Error())
+
+		return nil, nil
+	}
+
+	err = cablecourier.NewClient(redisClient, h.RedisChannel).BroadcastMessage(
 		event.ProtoName,
 		msg,
 		stream,
@@ -139,7 +161,7 @@ func (h *CableMessageEventHandler) Handle(ctx context.Context, event *Event, msg
 	if err != nil {
 		err = fmt.Errorf("failed to broadcast message: %w", err)
 		sentry.CaptureException(err)
-		h.Logger.Error(err)
+		h.Logger.Error(err.Error())
 
 		return nil, nil
 	}
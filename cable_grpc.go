@@ -18,9 +18,16 @@ type CableGRPC struct {
 }
 
 // InitCableGRPC initializes a Foundation service in AnyCable gRPC Server mode.
-func InitCableGRPC(name string) *CableGRPC {
+func InitCableGRPC(name string, opts ...InitOption) *CableGRPC {
 	return &CableGRPC{
-		Service: Init(name),
+		Service: Init(name, opts...),
+	}
+}
+
+// NewCableGRPC returns an AnyCable gRPC server mode running on the given service, see `Service.StartModes`.
+func NewCableGRPC(s *Service) *CableGRPC {
+	return &CableGRPC{
+		Service: s.newModeService(),
 	}
 }
 
@@ -47,15 +54,19 @@ func NewCableGRPCOptions() *CableGRPCOptions {
 
 // Start runs the Foundation as an AnyCable-compartible gRPC server.
 func (s *CableGRPC) Start(opts *CableGRPCOptions) {
+	s.Service.Start(s.Mode(opts))
+}
+
+// Mode returns the AnyCable gRPC server mode to run with `Service.StartModes`.
+func (s *CableGRPC) Mode(opts *CableGRPCOptions) *StartOptions {
 	s.Options = opts
 
-	startOpts := &StartOptions{
+	return &StartOptions{
 		ModeName:               "cable_grpc",
 		StartComponentsOptions: s.Options.StartComponentsOptions,
 		ServiceFunc:            s.ServiceFunc,
+		service:                s.Service,
 	}
-
-	s.Service.Start(startOpts)
 }
 
 func (s *CableGRPC) ServiceFunc(ctx context.Context) error {
@@ -88,12 +99,12 @@ func (s *CableGRPC) ServiceFunc(ctx context.Context) error {
 		if err := server.Serve(listener); err != nil {
 			err = fmt.Errorf("failed to start server: %w", err)
 			sentry.CaptureException(err)
-			s.Logger.Fatal(err)
+			logFatal(s.Logger, err.Error())
 		}
 	}()
 
 	<-ctx.Done()
-	server.GracefulStop()
+	s.gracefulStopGRPC(server)
 
 	return nil
 }
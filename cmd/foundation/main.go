@@ -11,7 +11,10 @@ func main() {
 	rootCmd.AddCommand(
 		c.DBMigrate,
 		c.DBRollback,
+		c.EventsDLQ,
 		c.New,
+		c.OutboxDead,
+		c.OutboxReplay,
 		c.Start,
 		c.Test,
 	)
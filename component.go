@@ -1,5 +1,20 @@
 package foundation
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrComponentNotRegistered is returned when the requested component is not registered.
+	ErrComponentNotRegistered = errors.New("component is not registered")
+
+	// ErrComponentTypeMismatch is returned when the requested component is of an unexpected type.
+	ErrComponentTypeMismatch = errors.New("component is of unexpected type")
+)
+
 // Component describes an interface for all components in the Foundation framework.
 // This could be an external service, a database, a cache, etc.
 type Component interface {
@@ -13,9 +28,37 @@ type Component interface {
 	Stop() error
 }
 
+// ComponentWithStartContext describes a component that can give up starting when the context is done,
+// e.g. on the start deadline of its `RetryPolicy`. Otherwise, the start given up on keeps running in
+// the background, and the component is stopped once it returns.
+type ComponentWithStartContext interface {
+	Component
+
+	// StartContext runs the component, giving up once the context is done
+	StartContext(ctx context.Context) error
+}
+
+// ComponentWithDependencies describes a component that depends on other components.
+//
+// Dependencies are started before the component and stopped after it. Components
+// that don't implement this interface and were added by the application are
+// started after all the system components.
+type ComponentWithDependencies interface {
+	Component
+
+	// Dependencies returns the names of the components this component depends on
+	Dependencies() []string
+}
+
 // GetComponent returns the component with the given name.
 func (s *Service) GetComponent(name string) Component {
-	for _, component := range s.Components {
+	root := s.root()
+
+	if root.componentsByName != nil {
+		return root.componentsByName[name]
+	}
+
+	for _, component := range root.Components {
 		if component.Name() == name {
 			return component
 		}
@@ -23,3 +66,29 @@ func (s *Service) GetComponent(name string) Component {
 
 	return nil
 }
+
+// GetComponentAs returns the component with the given name, asserting its type.
+func GetComponentAs[T Component](s *Service, name string) (T, error) {
+	var zero T
+
+	component := s.GetComponent(name)
+	if component == nil {
+		return zero, fmt.Errorf("`%s`: %w", name, ErrComponentNotRegistered)
+	}
+
+	c, ok := component.(T)
+	if !ok {
+		return zero, fmt.Errorf("`%s` is %T, not %s: %w", name, component, reflect.TypeFor[T](), ErrComponentTypeMismatch)
+	}
+
+	return c, nil
+}
+
+// WithComponent registers an application-defined component. Application components are
+// started after the system ones, unless they declare their dependencies explicitly
+// (see `ComponentWithDependencies`).
+func WithComponent(c Component) StartComponentsOption {
+	return func(s *Service) {
+		s.Components = append(s.Components, c)
+	}
+}
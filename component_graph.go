@@ -0,0 +1,206 @@
+package foundation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// componentGraph is a dependency graph of the service components split into levels.
+// Components of the same level don't depend on each other, so they can be started
+// and stopped in parallel.
+type componentGraph struct {
+	levels [][]Component
+
+	mu      sync.Mutex
+	started map[string]bool
+}
+
+// newComponentGraph builds a dependency graph of the given components. The `deps` function
+// returns the names of the components the given component depends on.
+func newComponentGraph(components []Component, deps func(Component) []string) (*componentGraph, error) {
+	var errs []error
+
+	// Index components by name
+	byName := make(map[string]Component, len(components))
+	for _, c := range components {
+		if _, ok := byName[c.Name()]; ok {
+			errs = append(errs, fmt.Errorf("component `%s` is registered more than once", c.Name()))
+			continue
+		}
+
+		byName[c.Name()] = c
+	}
+
+	// Resolve dependencies
+	dependencies := make(map[string][]string, len(byName))
+	dependents := make(map[string][]string, len(byName))
+	for _, c := range components {
+		if byName[c.Name()] != c {
+			continue
+		}
+
+		for _, dep := range deps(c) {
+			if _, ok := byName[dep]; !ok {
+				errs = append(errs, fmt.Errorf("component `%s` depends on unknown component `%s`", c.Name(), dep))
+				continue
+			}
+
+			dependencies[c.Name()] = append(dependencies[c.Name()], dep)
+			dependents[dep] = append(dependents[dep], c.Name())
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// Split components into levels (Kahn's algorithm). The original order is preserved
+	// within a level, so the startup log stays stable between runs.
+	pending := make(map[string]int, len(byName))
+	for name := range byName {
+		pending[name] = len(dependencies[name])
+	}
+
+	g := &componentGraph{
+		started: make(map[string]bool),
+	}
+
+	for len(pending) > 0 {
+		var level []Component
+		for _, c := range components {
+			if n, ok := pending[c.Name()]; ok && n == 0 && byName[c.Name()] == c {
+				level = append(level, c)
+			}
+		}
+
+		if len(level) == 0 {
+			return nil, fmt.Errorf("dependency cycle detected: %s", findCycle(pending, dependencies))
+		}
+
+		for _, c := range level {
+			delete(pending, c.Name())
+
+			for _, dependent := range dependents[c.Name()] {
+				pending[dependent]--
+			}
+		}
+
+		g.levels = append(g.levels, level)
+	}
+
+	return g, nil
+}
+
+// findCycle returns a human-readable dependency cycle among the pending components.
+func findCycle(pending map[string]int, dependencies map[string][]string) string {
+	visited := make(map[string]bool)
+
+	var (
+		path  []string
+		visit func(name string) []string
+	)
+
+	visit = func(name string) []string {
+		for i, n := range path {
+			if n == name {
+				return append(path[i:], name)
+			}
+		}
+
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+
+		path = append(path, name)
+		for _, dep := range dependencies[name] {
+			if _, ok := pending[dep]; !ok {
+				continue
+			}
+
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+
+		return nil
+	}
+
+	for name := range pending {
+		if cycle := visit(name); cycle != nil {
+			return strings.Join(cycle, " -> ")
+		}
+	}
+
+	return "unknown"
+}
+
+// start starts the components level by level, running components of the same level in parallel.
+// It stops at the first level that failed to start.
+func (g *componentGraph) start(start func(Component) error) error {
+	for _, level := range g.levels {
+		if err := g.runLevel(level, func(c Component) error {
+			if err := start(c); err != nil {
+				return fmt.Errorf("%s: %w", c.Name(), err)
+			}
+
+			g.mu.Lock()
+			g.started[c.Name()] = true
+			g.mu.Unlock()
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stop stops the started components in reverse dependency order, running components of the
+// same level in parallel.
+func (g *componentGraph) stop(stop func(Component)) {
+	for i := len(g.levels) - 1; i >= 0; i-- {
+		_ = g.runLevel(g.levels[i], func(c Component) error {
+			g.mu.Lock()
+			started := g.started[c.Name()]
+			delete(g.started, c.Name())
+			g.mu.Unlock()
+
+			if started {
+				stop(c)
+			}
+
+			return nil
+		})
+	}
+}
+
+func (g *componentGraph) runLevel(level []Component, f func(Component) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, c := range level {
+		wg.Add(1)
+
+		go func(c Component) {
+			defer wg.Done()
+
+			if err := f(c); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(c)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package foundation
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testComponent struct {
+	name         string
+	dependencies []string
+	startErr     error
+	health       error
+
+	mu     *sync.Mutex
+	events *[]string
+}
+
+func (c *testComponent) Health() error { return c.health }
+func (c *testComponent) Name() string  { return c.name }
+
+func (c *testComponent) Start() error {
+	c.record("start:" + c.name)
+	return c.startErr
+}
+
+func (c *testComponent) Stop() error {
+	c.record("stop:" + c.name)
+	return nil
+}
+
+func (c *testComponent) Dependencies() []string { return c.dependencies }
+
+func (c *testComponent) record(event string) {
+	if c.events == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, event)
+}
+
+func declaredDependencies(c Component) []string {
+	return c.(ComponentWithDependencies).Dependencies()
+}
+
+func TestComponentGraphLevels(t *testing.T) {
+	components := []Component{
+		&testComponent{name: "search", dependencies: []string{"postgresql", "redis"}},
+		&testComponent{name: "postgresql"},
+		&testComponent{name: "redis"},
+		&testComponent{name: "cache", dependencies: []string{"redis"}},
+	}
+
+	g, err := newComponentGraph(components, declaredDependencies)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{{"postgresql", "redis"}, {"search", "cache"}}
+	if len(g.levels) != len(expected) {
+		t.Fatalf("Expected %d levels, but got %d", len(expected), len(g.levels))
+	}
+
+	for i, level := range g.levels {
+		var names []string
+		for _, c := range level {
+			names = append(names, c.Name())
+		}
+
+		if strings.Join(names, ",") != strings.Join(expected[i], ",") {
+			t.Errorf("Expected level %d to be %v, but got %v", i, expected[i], names)
+		}
+	}
+}
+
+func TestComponentGraphErrors(t *testing.T) {
+	// Missing dependency
+	_, err := newComponentGraph([]Component{
+		&testComponent{name: "search", dependencies: []string{"elasticsearch"}},
+	}, declaredDependencies)
+	if err == nil || !strings.Contains(err.Error(), "unknown component `elasticsearch`") {
+		t.Errorf("Expected missing dependency error, but got %v", err)
+	}
+
+	// Cycle
+	_, err = newComponentGraph([]Component{
+		&testComponent{name: "a", dependencies: []string{"b"}},
+		&testComponent{name: "b", dependencies: []string{"c"}},
+		&testComponent{name: "c", dependencies: []string{"a"}},
+		&testComponent{name: "d"},
+	}, declaredDependencies)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle detected") {
+		t.Errorf("Expected cycle error, but got %v", err)
+	}
+
+	// Duplicate name
+	_, err = newComponentGraph([]Component{
+		&testComponent{name: "a"},
+		&testComponent{name: "a"},
+	}, declaredDependencies)
+	if err == nil || !strings.Contains(err.Error(), "registered more than once") {
+		t.Errorf("Expected duplicate error, but got %v", err)
+	}
+}
+
+func TestComponentGraphStartStop(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+
+	newComponent := func(name string, startErr error, deps ...string) *testComponent {
+		return &testComponent{name: name, dependencies: deps, startErr: startErr, mu: &mu, events: &events}
+	}
+
+	g, err := newComponentGraph([]Component{
+		newComponent("postgresql", nil),
+		newComponent("search", nil, "postgresql"),
+		newComponent("broken", errors.New("boom"), "search"),
+		newComponent("never", nil, "broken"),
+	}, declaredDependencies)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = g.start(Component.Start); err == nil || !strings.Contains(err.Error(), "broken: boom") {
+		t.Fatalf("Expected start error, but got %v", err)
+	}
+
+	g.stop(func(c Component) { _ = c.Stop() })
+
+	expected := "start:postgresql,start:search,start:broken,stop:search,stop:postgresql"
+	if strings.Join(events, ",") != expected {
+		t.Errorf("Expected events %s, but got %s", expected, strings.Join(events, ","))
+	}
+}
@@ -0,0 +1,205 @@
+package foundation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	ComponentStartDefaultInitialBackoff = 500 * time.Millisecond
+	ComponentStartDefaultMaxBackoff     = 10 * time.Second
+)
+
+// RetryPolicy describes how starting a component is retried on failure.
+type RetryPolicy struct {
+	// Attempts is the maximum number of start attempts, including the first one. Default: 1.
+	Attempts int
+
+	// InitialBackoff is the delay before the second attempt. It is doubled after every
+	// failed attempt. Default: 500ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. Default: 10s.
+	MaxBackoff time.Duration
+
+	// Timeout is the overall deadline for starting the component, including all attempts and
+	// delays between them. Zero means no deadline.
+	Timeout time.Duration
+
+	// Jitter is the fraction of the delay between attempts that is randomized, e.g. 0.2 for ±20%, so that
+	// the clients failed at the same time don't retry at the same time. Default: 0.
+	Jitter float64
+}
+
+// backoff returns the delay before the given (1-based) attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = ComponentStartDefaultInitialBackoff
+	}
+
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = ComponentStartDefaultMaxBackoff
+	}
+
+	delay := initial
+	for i := 2; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return p.jitter(min(delay, maxBackoff))
+}
+
+// jitter randomizes the given delay by the `Jitter` fraction.
+func (p *RetryPolicy) jitter(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return delay
+	}
+
+	return delay + time.Duration((rand.Float64()*2-1)*p.Jitter*float64(delay))
+}
+
+// WithStartRetry sets the start retry policy for all components.
+func WithStartRetry(policy RetryPolicy) StartComponentsOption {
+	return func(s *Service) {
+		s.startRetryPolicy = &policy
+	}
+}
+
+// WithComponentStartRetry sets the start retry policy for the component with the given name,
+// overriding the one set with `WithStartRetry`.
+func WithComponentStartRetry(name string, policy RetryPolicy) StartComponentsOption {
+	return func(s *Service) {
+		if s.componentStartRetryPolicies == nil {
+			s.componentStartRetryPolicies = make(map[string]*RetryPolicy)
+		}
+
+		s.componentStartRetryPolicies[name] = &policy
+	}
+}
+
+// startRetryPolicyFor returns the start retry policy for the given component.
+func (s *Service) startRetryPolicyFor(name string) *RetryPolicy {
+	if policy, ok := s.componentStartRetryPolicies[name]; ok {
+		return policy
+	}
+
+	if s.startRetryPolicy != nil {
+		return s.startRetryPolicy
+	}
+
+	return &RetryPolicy{Attempts: 1}
+}
+
+// startComponent starts the component respecting its retry policy.
+func (s *Service) startComponent(component Component) error {
+	policy := s.startRetryPolicyFor(component.Name())
+	log := s.Logger.With("component", component.Name())
+
+	attempts := max(policy.Attempts, 1)
+
+	ctx := context.Background()
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
+	var (
+		err error
+
+		// abandoned receives the result of the start given up on, still running in the background
+		abandoned <-chan error
+	)
+
+	// The abandoned start is stopped once it returns, so that what it produced is closed
+	defer func() {
+		if abandoned != nil {
+			go stopAbandonedStart(log, component, abandoned)
+		}
+	}()
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := policy.backoff(attempt)
+			log.Info(fmt.Sprintf("Retrying to start `%s` in %s (attempt %d/%d)", component.Name(), delay, attempt, attempts))
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return fmt.Errorf("start deadline of %s exceeded: %w", policy.Timeout, err)
+			}
+		}
+
+		// The component is never started twice at once
+		if abandoned != nil {
+			select {
+			case <-abandoned:
+				abandoned = nil
+			case <-ctx.Done():
+				return fmt.Errorf("start deadline of %s exceeded: %w", policy.Timeout, err)
+			}
+		}
+
+		if abandoned, err = startWithContext(ctx, component); err == nil {
+			if attempt > 1 {
+				log.Info(fmt.Sprintf("Component `%s` started after %d attempts", component.Name(), attempt))
+			}
+
+			return nil
+		}
+
+		log.Warn(fmt.Sprintf("Failed to start `%s` (attempt %d/%d)", component.Name(), attempt, attempts), "attempt", attempt, "error", err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return err
+}
+
+// startWithContext starts the component, giving up once the context is done.
+//
+// N.B.: Unless the component implements `ComponentWithStartContext`, the abandoned start keeps
+// running in the background until it returns on its own. Its result is then sent to the returned
+// channel.
+func startWithContext(ctx context.Context, component Component) (<-chan error, error) {
+	if c, ok := component.(ComponentWithStartContext); ok {
+		return nil, c.StartContext(ctx)
+	}
+
+	if ctx.Done() == nil {
+		return nil, component.Start()
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- component.Start()
+	}()
+
+	select {
+	case err := <-result:
+		return nil, err
+	case <-ctx.Done():
+		return result, fmt.Errorf("start timed out: %w", ctx.Err())
+	}
+}
+
+// stopAbandonedStart waits for the abandoned start of the component to return, and stops the
+// component if it succeeded.
+func stopAbandonedStart(log *slog.Logger, component Component, abandoned <-chan error) {
+	if err := <-abandoned; err != nil {
+		return
+	}
+
+	log.Warn(fmt.Sprintf("Stopping `%s` started after its start deadline", component.Name()))
+
+	if err := component.Stop(); err != nil {
+		log.Error(fmt.Sprintf("Failed to stop `%s`", component.Name()), "error", err)
+	}
+}
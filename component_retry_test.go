@@ -0,0 +1,156 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flakyComponent struct {
+	testComponent
+
+	failures int
+	attempts int
+	delay    time.Duration
+}
+
+func (c *flakyComponent) Start() error {
+	c.attempts++
+	time.Sleep(c.delay)
+
+	if c.attempts <= c.failures {
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, e := range expected {
+		if got := policy.backoff(i + 2); got != e*time.Millisecond {
+			t.Errorf("Expected backoff before attempt %d to be %s, but got %s", i+2, e*time.Millisecond, got)
+		}
+	}
+
+	policy.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(3); got < 160*time.Millisecond || got > 240*time.Millisecond {
+			t.Fatalf("Expected backoff with jitter to be within 20%% of 200ms, but got %s", got)
+		}
+	}
+}
+
+func TestStartComponentWithRetry(t *testing.T) {
+	app := &Service{
+		Config: &Config{},
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
+	}
+
+	// Succeeds after two failures
+	c := &flakyComponent{testComponent: testComponent{name: "postgresql"}, failures: 2}
+	WithStartRetry(RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond})(app)
+
+	if err := app.startComponent(c); err != nil {
+		t.Errorf("Expected component to start, but got %v", err)
+	}
+
+	if c.attempts != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", c.attempts)
+	}
+
+	// Per-component policy overrides the global one
+	c = &flakyComponent{testComponent: testComponent{name: "redis"}, failures: 2}
+	WithComponentStartRetry("redis", RetryPolicy{Attempts: 2, InitialBackoff: time.Millisecond})(app)
+
+	if err := app.startComponent(c); err == nil {
+		t.Error("Expected component to fail to start")
+	}
+
+	if c.attempts != 2 {
+		t.Errorf("Expected 2 attempts, but got %d", c.attempts)
+	}
+
+	// Deadline is respected
+	c = &flakyComponent{testComponent: testComponent{name: "kafka"}, failures: 10, delay: 20 * time.Millisecond}
+	WithComponentStartRetry("kafka", RetryPolicy{Attempts: 10, InitialBackoff: time.Millisecond, Timeout: 30 * time.Millisecond})(app)
+
+	err := app.startComponent(c)
+	if err == nil || !strings.Contains(err.Error(), "timed out") && !strings.Contains(err.Error(), "deadline") {
+		t.Errorf("Expected deadline error, but got %v", err)
+	}
+}
+
+type slowComponent struct {
+	testComponent
+
+	delay   time.Duration
+	stopped chan struct{}
+}
+
+func (c *slowComponent) Start() error {
+	time.Sleep(c.delay)
+	return nil
+}
+
+func (c *slowComponent) Stop() error {
+	close(c.stopped)
+	return nil
+}
+
+func TestStartComponentStopsAbandonedStart(t *testing.T) {
+	app := &Service{
+		Config: &Config{},
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
+	}
+
+	c := &slowComponent{testComponent: testComponent{name: "kafka"}, delay: 50 * time.Millisecond, stopped: make(chan struct{})}
+	WithComponentStartRetry("kafka", RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond, Timeout: 10 * time.Millisecond})(app)
+
+	if err := app.startComponent(c); err == nil {
+		t.Fatal("Expected deadline error")
+	}
+
+	// The start given up on succeeds later, what it produced is closed
+	select {
+	case <-c.stopped:
+	case <-time.After(time.Second):
+		t.Error("Expected the component started after its deadline to be stopped")
+	}
+}
+
+type contextComponent struct {
+	testComponent
+
+	attempts int
+}
+
+func (c *contextComponent) StartContext(ctx context.Context) error {
+	c.attempts++
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func TestStartComponentWithContext(t *testing.T) {
+	app := &Service{
+		Config: &Config{},
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
+	}
+
+	c := &contextComponent{testComponent: testComponent{name: "postgresql"}}
+	WithComponentStartRetry("postgresql", RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond, Timeout: 10 * time.Millisecond})(app)
+
+	if err := app.startComponent(c); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error, but got %v", err)
+	}
+
+	if c.attempts != 1 {
+		t.Errorf("Expected 1 attempt, but got %d", c.attempts)
+	}
+}
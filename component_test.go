@@ -0,0 +1,40 @@
+package foundation
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	fpg "github.com/foundation-go/foundation/postgresql"
+)
+
+func TestGetComponentAs(t *testing.T) {
+	app := &Service{
+		Config: &Config{},
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
+	}
+
+	WithComponent(&testComponent{name: "search"})(app)
+	WithComponent(&testComponent{name: fpg.ComponentName})(app)
+
+	// Registered component of the expected type
+	c, err := GetComponentAs[*testComponent](app, "search")
+	if err != nil || c.Name() != "search" {
+		t.Errorf("Expected `search` component, but got %v (%v)", c, err)
+	}
+
+	// Missing component
+	if _, err = GetComponentAs[*testComponent](app, "cache"); !errors.Is(err, ErrComponentNotRegistered) {
+		t.Errorf("Expected ErrComponentNotRegistered, but got %v", err)
+	}
+
+	// Component of an unexpected type
+	if _, err = GetComponentAs[*fpg.Component](app, fpg.ComponentName); !errors.Is(err, ErrComponentTypeMismatch) {
+		t.Errorf("Expected ErrComponentTypeMismatch, but got %v", err)
+	}
+
+	// Non-fatal accessors return an error instead of terminating the service
+	if _, fErr := app.LookupPostgreSQL(); fErr == nil {
+		t.Error("Expected LookupPostgreSQL to fail")
+	}
+}
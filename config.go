@@ -0,0 +1,413 @@
+package foundation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileBaseName is the base name of the configuration files, see `ConfigLoader`.
+const ConfigFileBaseName = "foundation"
+
+// configManifestTables are the tables of the `foundation.toml` application manifest of the CLI, which are
+// not settings.
+var configManifestTables = map[string]bool{"foundation": true, "app": true}
+
+// identifierPattern matches the PostgreSQL identifiers that don't need quoting.
+var identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// ConfigLoader resolves configuration settings from several layers, in the order of precedence:
+//
+//  1. environment variables;
+//  2. files referenced by `_FILE`-suffixed environment variables (e.g. `DATABASE_URL_FILE`),
+//     as provided by Docker and Kubernetes secrets;
+//  3. secret providers, see `SecretProvider`;
+//  4. the service- and environment-specific configuration file (`<service>.<FOUNDATION_ENV>.toml`);
+//  5. the service-specific configuration file (`<service>.toml`);
+//  6. the environment-specific configuration file (`foundation.<FOUNDATION_ENV>.toml`);
+//  7. the common configuration file (`foundation.toml`);
+//  8. the default values.
+//
+// Configuration files are looked up in the `FOUNDATION_CONFIG_DIR` directory (default: the current
+// working directory) and may be written in TOML or YAML (`.yaml`, `.yml`). Nested keys are mapped
+// to environment variable names, e.g. `producer.batch_size` in the `[kafka]` table stands for
+// `KAFKA_PRODUCER_BATCH_SIZE`, lists are joined with commas. The `[foundation]` and `[app]` tables
+// of the CLI application manifest are not settings and are skipped.
+//
+// Conversion errors are collected rather than swallowed, see `ConfigLoader.Err`.
+type ConfigLoader struct {
+	values          map[string]string
+	secretProviders []SecretProvider
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewConfigLoader returns a new ConfigLoader with the common configuration files read.
+func NewConfigLoader() *ConfigLoader {
+	return NewServiceConfigLoader("")
+}
+
+// NewServiceConfigLoader returns a new ConfigLoader with the common configuration files and the ones of
+// the given service read.
+func NewServiceConfigLoader(serviceName string) *ConfigLoader {
+	l := &ConfigLoader{
+		values: make(map[string]string),
+	}
+
+	names := []string{ConfigFileBaseName, fmt.Sprintf("%s.%s", ConfigFileBaseName, FoundationEnv())}
+	if serviceName != "" && serviceName != ConfigFileBaseName {
+		names = append(names, serviceName, fmt.Sprintf("%s.%s", serviceName, FoundationEnv()))
+	}
+
+	dir := GetEnvOrString("FOUNDATION_CONFIG_DIR", ".")
+	for _, name := range names {
+		if err := l.readFile(dir, name); err != nil {
+			l.AddError(err)
+		}
+	}
+
+	if secretsDir := os.Getenv("FOUNDATION_SECRETS_DIR"); secretsDir != "" {
+		l.AddSecretProvider(NewFileSecretProvider(secretsDir))
+	}
+
+	return l
+}
+
+// AddSecretProvider adds a secret provider to consult, after the ones added before.
+func (l *ConfigLoader) AddSecretProvider(p SecretProvider) {
+	l.secretProviders = append(l.secretProviders, p)
+}
+
+// readFile reads the first configuration file found with the given name and any of the supported extensions.
+func (l *ConfigLoader) readFile(dir, name string) error {
+	for _, ext := range []string{".toml", ".yaml", ".yml"} {
+		path := filepath.Join(dir, name+ext)
+
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read config file `%s`: %w", path, err)
+		}
+
+		var data map[string]interface{}
+		if ext == ".toml" {
+			err = toml.Unmarshal(content, &data)
+		} else {
+			err = yaml.Unmarshal(content, &data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse config file `%s`: %w", path, err)
+		}
+
+		for table := range configManifestTables {
+			delete(data, table)
+		}
+
+		flattenConfig("", data, l.values)
+
+		return nil
+	}
+
+	return nil
+}
+
+// flattenConfig maps nested configuration keys to environment variable names.
+func flattenConfig(prefix string, data map[string]interface{}, values map[string]string) {
+	for k, v := range data {
+		key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(k))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch value := v.(type) {
+		case map[string]interface{}:
+			flattenConfig(key, value, values)
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// Lookup returns the raw value of the setting and whether it is set in any of the layers.
+// Secrets that cannot be read are reported by `Err`.
+func (l *ConfigLoader) Lookup(key string) (string, bool) {
+	value, ok, err := l.resolve(key)
+	if err != nil {
+		l.AddError(err)
+	}
+
+	return value, ok
+}
+
+// resolve returns the raw value of the setting, reading secrets from their sources every time.
+func (l *ConfigLoader) resolve(key string) (string, bool, error) {
+	if value := os.Getenv(key); value != "" {
+		return value, true, nil
+	}
+
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("`%s_FILE`: failed to read secret file: %w", key, err)
+		}
+
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+
+	for _, p := range l.secretProviders {
+		value, ok, err := p.GetSecret(key)
+		if err != nil {
+			return "", false, fmt.Errorf("`%s`: failed to get secret: %w", key, err)
+		}
+
+		if ok {
+			return value, true, nil
+		}
+	}
+
+	value, ok := l.values[key]
+
+	return value, ok && value != "", nil
+}
+
+// String returns the value of the setting, or defaultValue if it is not set.
+func (l *ConfigLoader) String(key string, defaultValue string) string {
+	if value, ok := l.Lookup(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Int returns the value of the setting, or defaultValue if it is not set or invalid.
+// Invalid values are reported by `Err`.
+func (l *ConfigLoader) Int(key string, defaultValue int) int {
+	value, ok := l.Lookup(key)
+	if !ok {
+		return defaultValue
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		l.AddError(fmt.Errorf("`%s`: invalid integer %q", key, value))
+		return defaultValue
+	}
+
+	return result
+}
+
+// Bool returns the value of the setting, or defaultValue if it is not set or invalid.
+// Invalid values are reported by `Err`.
+func (l *ConfigLoader) Bool(key string, defaultValue bool) bool {
+	value, ok := l.Lookup(key)
+	if !ok {
+		return defaultValue
+	}
+
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		l.AddError(fmt.Errorf("`%s`: invalid boolean %q", key, value))
+		return defaultValue
+	}
+
+	return result
+}
+
+// Strings returns the comma-separated list of values of the setting, skipping blank items.
+func (l *ConfigLoader) Strings(key string, defaultValue []string) []string {
+	value, ok := l.Lookup(key)
+	if !ok {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// AddError records a configuration error to be reported by `Err`.
+func (l *ConfigLoader) AddError(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errs = append(l.errs, err)
+}
+
+// Err returns the errors occurred while loading the configuration, if any.
+func (l *ConfigLoader) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.errs) == 0 {
+		return nil
+	}
+
+	return &ConfigError{Errors: l.errs}
+}
+
+// ConfigError lists all the problems found in the configuration.
+type ConfigError struct {
+	Errors []error
+}
+
+func (e *ConfigError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	sort.Strings(messages)
+
+	return fmt.Sprintf("invalid configuration:\n - %s", strings.Join(messages, "\n - "))
+}
+
+// Unwrap returns the underlying errors.
+func (e *ConfigError) Unwrap() []error {
+	return e.Errors
+}
+
+// Validate checks the configuration of the enabled components, reporting all the missing and
+// invalid settings at once, along with the errors occurred while loading the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.loadErr != nil {
+		var configErr *ConfigError
+		if errors.As(c.loadErr, &configErr) {
+			errs = append(errs, configErr.Errors...)
+		} else {
+			errs = append(errs, c.loadErr)
+		}
+	}
+
+	required := func(key, value, reason string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("`%s` is required %s", key, reason))
+		}
+	}
+	positive := func(key string, value int) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("`%s` must be positive, got %d", key, value))
+		}
+	}
+
+	if c.Database.Enabled {
+		positive("DATABASE_POOL", c.Database.Pool)
+	}
+
+	if c.Outbox.Enabled {
+		required("DATABASE_URL", c.Database.URL, "by the outbox")
+	}
+
+	if c.Database.AutoMigrate {
+		required("DATABASE_URL", c.Database.URL, "by the framework migrations")
+	}
+
+	// The outbox settings are only used by the outbox, and by the framework migrations creating its tables
+	if c.Outbox.Enabled || c.Database.AutoMigrate {
+		if c.Outbox.Retention < 0 {
+			errs = append(errs, fmt.Errorf("`OUTBOX_RETENTION` must not be negative, got %d", c.Outbox.Retention))
+		}
+
+		identifier := func(key, value string) {
+			if value != "" && !identifierPattern.MatchString(value) {
+				errs = append(errs, fmt.Errorf("`%s` must be a lowercase identifier, got %q", key, value))
+			}
+		}
+		identifier("OUTBOX_SCHEMA", c.Outbox.Schema)
+		identifier("OUTBOX_TABLE", c.Outbox.Table)
+		identifier("OUTBOX_DEAD_TABLE", c.Outbox.DeadTable)
+		required("OUTBOX_TABLE", c.Outbox.Table, "by the outbox")
+		required("OUTBOX_DEAD_TABLE", c.Outbox.DeadTable, "by the outbox")
+
+		switch c.Outbox.Partitioning {
+		case "", OutboxPartitioningDaily, OutboxPartitioningMonthly:
+		default:
+			errs = append(errs, fmt.Errorf("`OUTBOX_PARTITIONING` must be `%s` or `%s`, got %q", OutboxPartitioningDaily, OutboxPartitioningMonthly, c.Outbox.Partitioning))
+		}
+	}
+
+	if c.Kafka.Consumer.Enabled || c.Kafka.Producer.Enabled {
+		if len(c.Kafka.Brokers) == 0 {
+			errs = append(errs, errors.New("`KAFKA_BROKERS` is required by Kafka"))
+		}
+
+		if c.Kafka.SASL.Username != "" || c.Kafka.SASL.Password != "" {
+			required("KAFKA_SASL_USERNAME", c.Kafka.SASL.Username, "when SASL is used")
+			required("KAFKA_SASL_PASSWORD", c.Kafka.SASL.Password, "when SASL is used")
+
+			switch strings.ToLower(c.Kafka.SASL.Protocol) {
+			case "plain", "scram-sha-512":
+			default:
+				errs = append(errs, fmt.Errorf("`KAFKA_SASL_PROTOCOL` must be `plain` or `scram-sha-512`, got %q", c.Kafka.SASL.Protocol))
+			}
+		}
+	}
+
+	if c.Kafka.Producer.Enabled {
+		positive("KAFKA_PRODUCER_BATCH_SIZE", c.Kafka.Producer.BatchSize)
+	}
+
+	if c.Metrics.Enabled && (c.Metrics.Port <= 0 || c.Metrics.Port > 65535) {
+		errs = append(errs, fmt.Errorf("`METRICS_PORT` must be a valid port, got %d", c.Metrics.Port))
+	}
+
+	if c.Admin.Enabled && !c.Metrics.Enabled {
+		errs = append(errs, errors.New("`METRICS_ENABLED` is required by the admin API"))
+	}
+
+	if c.Redis.Enabled {
+		required("REDIS_URL", c.Redis.URL, "by Redis")
+	}
+
+	if c.JobsEnqueuer.Enabled {
+		required("REDIS_URL", c.JobsEnqueuer.URL, "by the jobs enqueuer")
+		positive("REDIS_POOL", c.JobsEnqueuer.Pool)
+	}
+
+	if c.Shutdown.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("`SHUTDOWN_DRAIN_DELAY` must not be negative, got %d", c.Shutdown.DrainDelay))
+	}
+	positive("SHUTDOWN_TIMEOUT", c.Shutdown.Timeout)
+
+	if _, err := parseLogLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("`LOG_LEVEL` must be a valid log level, got %q", c.Log.Level))
+	}
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("`PORT` must be a valid port, got %d", c.Server.Port))
+	}
+
+	if c.Secrets.RotationInterval < 0 {
+		errs = append(errs, fmt.Errorf("`SECRETS_ROTATION_INTERVAL` must not be negative, got %d", c.Secrets.RotationInterval))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &ConfigError{Errors: errs}
+}
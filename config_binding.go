@@ -0,0 +1,277 @@
+package foundation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags recognized by `BindConfig`.
+const (
+	// ConfigTagEnv is the name of the setting (environment variable) the field is bound to.
+	ConfigTagEnv = "env"
+	// ConfigTagDefault is the value used when the setting is not set.
+	ConfigTagDefault = "default"
+	// ConfigTagRequired marks the setting as required (`required:"true"`).
+	ConfigTagRequired = "required"
+	// ConfigTagDescription is the description of the setting used in the generated reference.
+	ConfigTagDescription = "desc"
+	// ConfigTagPrefix is the prefix added to the settings of a nested struct.
+	ConfigTagPrefix = "prefix"
+)
+
+// configField describes a struct field bound to a configuration setting.
+type configField struct {
+	Key         string
+	Default     string
+	Description string
+	Required    bool
+	Type        reflect.Type
+
+	value reflect.Value
+}
+
+// BindConfig populates the struct pointed to by dst with the settings resolved by the loader,
+// according to the struct tags:
+//
+//	type Config struct {
+//		MaxLen  int           `env:"CHATS_MAX_LEN" default:"500" desc:"Maximum message length"`
+//		Timeout time.Duration `env:"CHATS_TIMEOUT" default:"5s"`
+//		Admins  []string      `env:"CHATS_ADMINS"`
+//		Limits  map[string]int `env:"CHATS_LIMITS"` // e.g. `free=10,pro=100`
+//		Storage struct {
+//			Bucket string `env:"BUCKET" required:"true"`
+//		} `prefix:"CHATS_STORAGE_"`
+//	}
+//
+// Strings, booleans, numbers, durations, slices (comma-separated) and maps (comma-separated
+// `key=value` pairs) are supported. All the missing and invalid settings are reported at once.
+func BindConfig(l *ConfigLoader, dst interface{}) error {
+	fields, err := configFields(dst)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, f := range fields {
+		raw, ok := l.Lookup(f.Key)
+		if !ok {
+			if f.Required {
+				errs = append(errs, fmt.Errorf("`%s` is required", f.Key))
+				continue
+			}
+
+			if f.Default == "" {
+				continue
+			}
+
+			raw = f.Default
+		}
+
+		if err = setConfigValue(f.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("`%s`: %w", f.Key, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ConfigError{Errors: errs}
+	}
+
+	return nil
+}
+
+// ConfigReference returns a Markdown reference of the settings the given config struct is bound to,
+// in the format of the Foundation `ENV.md`.
+func ConfigReference(cfg interface{}) (string, error) {
+	// Described on a zero copy, so that the nil nested structs of cfg are left as is
+	if v := reflect.ValueOf(cfg); v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		cfg = reflect.New(v.Elem().Type()).Interface()
+	}
+
+	fields, err := configFields(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for _, f := range fields {
+		line := fmt.Sprintf("- `%s`", f.Key)
+
+		var details []string
+		if f.Description != "" {
+			details = append(details, strings.TrimSuffix(f.Description, ".")+".")
+		}
+		if f.Required {
+			details = append(details, "Required.")
+		}
+		if f.Default != "" {
+			details = append(details, fmt.Sprintf("Default: `%s`.", f.Default))
+		}
+		details = append(details, fmt.Sprintf("Type: `%s`.", f.Type))
+
+		sb.WriteString(line + ": " + strings.Join(details, " ") + "\n")
+	}
+
+	return sb.String(), nil
+}
+
+// configFields returns the fields of the struct pointed to by cfg bound to configuration settings.
+func configFields(cfg interface{}) ([]configField, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config must be a non-nil pointer to a struct, got %T", cfg)
+	}
+
+	var fields []configField
+	collectConfigFields(v.Elem(), "", &fields)
+
+	return fields, nil
+}
+
+func collectConfigFields(v reflect.Value, prefix string, fields *[]configField) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		if key, ok := sf.Tag.Lookup(ConfigTagEnv); ok {
+			*fields = append(*fields, configField{
+				Key:         prefix + key,
+				Default:     sf.Tag.Get(ConfigTagDefault),
+				Description: sf.Tag.Get(ConfigTagDescription),
+				Required:    sf.Tag.Get(ConfigTagRequired) == "true",
+				Type:        sf.Type,
+				value:       fv,
+			})
+
+			continue
+		}
+
+		// Nested structs
+		nestedPrefix := prefix + sf.Tag.Get(ConfigTagPrefix)
+
+		switch {
+		case sf.Type.Kind() == reflect.Struct:
+			collectConfigFields(fv, nestedPrefix, fields)
+		case sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct:
+			if fv.IsNil() {
+				fv.Set(reflect.New(sf.Type.Elem()))
+			}
+
+			collectConfigFields(fv.Elem(), nestedPrefix, fields)
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setConfigValue parses the raw setting value into the field.
+func setConfigValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items := splitConfigList(raw)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+
+		for i, item := range items {
+			if err := setConfigValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+
+		for _, item := range splitConfigList(raw) {
+			k, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid map entry %q, expected `key=value`", item)
+			}
+
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := setConfigValue(key, strings.TrimSpace(k)); err != nil {
+				return err
+			}
+
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := setConfigValue(value, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(key, value)
+		}
+
+		v.Set(m)
+	default:
+		return errors.New("unsupported type " + v.Type().String())
+	}
+
+	return nil
+}
+
+func splitConfigList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// WithAppConfig binds the application configuration to the given struct pointer at `Init`, see `BindConfig`.
+// The service fails to start if any of the settings is missing or invalid. The struct is available as
+// `Service.AppConfig` afterwards.
+func WithAppConfig(dst interface{}) InitOption {
+	return func(s *Service) {
+		s.AppConfig = dst
+	}
+}
@@ -0,0 +1,101 @@
+package foundation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAppConfig struct {
+	MaxLen  int            `env:"CHATS_MAX_LEN" default:"500" desc:"Maximum message length"`
+	Timeout time.Duration  `env:"CHATS_TIMEOUT" default:"5s"`
+	Admins  []string       `env:"CHATS_ADMINS"`
+	Limits  map[string]int `env:"CHATS_LIMITS"`
+	Storage struct {
+		Bucket string `env:"BUCKET" required:"true"`
+	} `prefix:"CHATS_STORAGE_"`
+	Search *struct {
+		Enabled bool `env:"ENABLED"`
+	} `prefix:"CHATS_SEARCH_"`
+}
+
+func TestBindConfig(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+	t.Setenv("CHATS_TIMEOUT", "1m")
+	t.Setenv("CHATS_ADMINS", "alice, bob")
+	t.Setenv("CHATS_LIMITS", "free=10,pro=100")
+	t.Setenv("CHATS_STORAGE_BUCKET", "chats")
+	t.Setenv("CHATS_SEARCH_ENABLED", "true")
+
+	var cfg testAppConfig
+	if err := BindConfig(NewConfigLoader(), &cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.MaxLen != 500 {
+		t.Errorf("Expected the default max length, but got %d", cfg.MaxLen)
+	}
+
+	if cfg.Timeout != time.Minute {
+		t.Errorf("Expected timeout to be 1m, but got %s", cfg.Timeout)
+	}
+
+	if strings.Join(cfg.Admins, ",") != "alice,bob" {
+		t.Errorf("Unexpected admins: %v", cfg.Admins)
+	}
+
+	if cfg.Limits["free"] != 10 || cfg.Limits["pro"] != 100 {
+		t.Errorf("Unexpected limits: %v", cfg.Limits)
+	}
+
+	if cfg.Storage.Bucket != "chats" {
+		t.Errorf("Expected bucket to be chats, but got %s", cfg.Storage.Bucket)
+	}
+
+	if cfg.Search == nil || !cfg.Search.Enabled {
+		t.Errorf("Expected search to be enabled")
+	}
+}
+
+func TestBindConfigErrors(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+	t.Setenv("CHATS_MAX_LEN", "many")
+	t.Setenv("CHATS_LIMITS", "free")
+
+	var cfg testAppConfig
+	err := BindConfig(NewConfigLoader(), &cfg)
+
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a configuration error, but got %v", err)
+	}
+
+	for _, expected := range []string{"CHATS_MAX_LEN", "CHATS_LIMITS", "CHATS_STORAGE_BUCKET"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected the error to mention `%s`, but got: %v", expected, err)
+		}
+	}
+}
+
+func TestConfigReference(t *testing.T) {
+	var cfg testAppConfig
+	reference, err := ConfigReference(&cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.Search != nil {
+		t.Error("Expected the described config to be left as is")
+	}
+
+	for _, expected := range []string{
+		"- `CHATS_MAX_LEN`: Maximum message length. Default: `500`. Type: `int`.\n",
+		"- `CHATS_STORAGE_BUCKET`: Required. Type: `string`.\n",
+		"- `CHATS_SEARCH_ENABLED`: Type: `bool`.\n",
+	} {
+		if !strings.Contains(reference, expected) {
+			t.Errorf("Expected the reference to contain %q, but got:\n%s", expected, reference)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package foundation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigLoaderLayers(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("FOUNDATION_CONFIG_DIR", dir)
+	t.Setenv("FOUNDATION_ENV", "test")
+
+	common := `
+[database]
+url = "postgres://localhost/common"
+pool = 10
+
+[kafka]
+brokers = ["kafka-1:9092", "kafka-2:9092"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "foundation.toml"), []byte(common), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := `
+database:
+  url: postgres://localhost/test
+metrics:
+  port: 9999
+`
+	if err := os.WriteFile(filepath.Join(dir, "foundation.test.yaml"), []byte(env), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("METRICS_PORT", "8080")
+
+	config := NewConfig()
+
+	// Environment-specific file overrides the common one
+	if config.Database.URL != "postgres://localhost/test" {
+		t.Errorf("Expected database URL from the environment file, but got %s", config.Database.URL)
+	}
+
+	// Common file overrides the defaults
+	if config.Database.Pool != 10 {
+		t.Errorf("Expected database pool to be 10, but got %d", config.Database.Pool)
+	}
+
+	if strings.Join(config.Kafka.Brokers, ",") != "kafka-1:9092,kafka-2:9092" {
+		t.Errorf("Unexpected Kafka brokers: %v", config.Kafka.Brokers)
+	}
+
+	// Environment variables override the files
+	if config.Metrics.Port != 8080 {
+		t.Errorf("Expected metrics port to be 8080, but got %d", config.Metrics.Port)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected configuration to be valid, but got %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+	t.Setenv("KAFKA_BROKERS", "")
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	t.Setenv("OUTBOX_RETENTION", "-1")
+	t.Setenv("OUTBOX_SCHEMA", "Chats")
+	t.Setenv("OUTBOX_PARTITIONING", "weekly")
+
+	config := NewConfig()
+	config.Kafka.Producer.Enabled = true
+	config.Outbox.Enabled = true
+
+	if len(config.Kafka.Brokers) != 0 {
+		t.Errorf("Expected no Kafka brokers, but got %q", config.Kafka.Brokers)
+	}
+
+	err := config.Validate()
+
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected ConfigError, but got %v", err)
+	}
+
+	for _, problem := range []string{"SHUTDOWN_TIMEOUT", "KAFKA_BROKERS", "DATABASE_URL", "OUTBOX_RETENTION", "OUTBOX_SCHEMA", "OUTBOX_PARTITIONING"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected `%s` to be reported, but got %v", problem, err)
+		}
+	}
+}
+
+func TestConfigValidateOutboxDisabled(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+	t.Setenv("OUTBOX_SCHEMA", "Chats")
+	t.Setenv("OUTBOX_PARTITIONING", "weekly")
+
+	// The outbox settings are not used without the outbox
+	config := NewConfig()
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected the outbox settings to be ignored, but got %v", err)
+	}
+
+	config.Database.AutoMigrate = true
+	config.Database.URL = "postgres://localhost:5432/db"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "OUTBOX_SCHEMA") {
+		t.Errorf("Expected `OUTBOX_SCHEMA` to be validated for the framework migrations, but got %v", err)
+	}
+}
+
+func TestConfigLoaderServiceFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("FOUNDATION_CONFIG_DIR", dir)
+	t.Setenv("FOUNDATION_ENV", "production")
+	t.Setenv("PORT", "")
+	t.Setenv("LOG_LEVEL", "")
+
+	files := map[string]string{
+		// The CLI application manifest is not part of the settings
+		"foundation.toml": `
+[foundation]
+version = "0.1.0"
+
+[app]
+name = "clubchat"
+
+[database]
+pool = 10
+`,
+		"foundation.production.toml": "port = 8000\nlog_level = \"warn\"\n",
+		"chats.toml":                 "port = 9000\n\n[anycable]\nredis_channel = \"chats\"\n",
+		"chats.production.toml":      "log_level = \"error\"\n",
+		"users.toml":                 "port = 7000\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := NewServiceConfigLoader("chats")
+	config := NewConfigFrom(l)
+
+	if config.Database.Pool != 10 {
+		t.Errorf("Expected database pool from the common file, but got %d", config.Database.Pool)
+	}
+
+	// The service file overrides the environment one, and is overridden by the service environment one
+	if config.Server.Port != 9000 {
+		t.Errorf("Expected port from the service file, but got %d", config.Server.Port)
+	}
+
+	if config.Log.Level != "error" {
+		t.Errorf("Expected log level from the service environment file, but got %s", config.Log.Level)
+	}
+
+	if config.Cable.RedisChannel != "chats" {
+		t.Errorf("Expected AnyCable channel from the service file, but got %s", config.Cable.RedisChannel)
+	}
+
+	for _, key := range []string{"FOUNDATION_VERSION", "APP_NAME"} {
+		if _, ok := l.Lookup(key); ok {
+			t.Errorf("Expected `%s` of the application manifest to be skipped", key)
+		}
+	}
+
+	// Other services' files are not read
+	if config := NewConfig(); config.Server.Port != 8000 {
+		t.Errorf("Expected port from the environment file, but got %d", config.Server.Port)
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	log "github.com/sirupsen/logrus"
 )
 
 type CtxKey string
@@ -13,9 +12,11 @@ type CtxKey string
 const (
 	CtxKeyAccessToken   CtxKey = "access_token"
 	CtxKeyAuthenticated CtxKey = "authenticated"
+	CtxKeyCausationID   CtxKey = "causation_id"
 	CtxKeyClientID      CtxKey = "client_id"
 	CtxKeyCorrelationID CtxKey = "correlation_id"
 	CtxKeyLogger        CtxKey = "logger"
+	CtxKeyMode          CtxKey = "mode"
 	CtxKeyScopes        CtxKey = "scopes"
 	CtxKeyTX            CtxKey = "tx"
 	CtxKeyUserID        CtxKey = "user_id"
@@ -67,6 +68,17 @@ func WithCorrelationID(ctx context.Context, correlationID string) context.Contex
 	return context.WithValue(ctx, CtxKeyCorrelationID, correlationID)
 }
 
+// GetCausationID returns the ID of the event or request being handled from the context, if any.
+func GetCausationID(ctx context.Context) string {
+	causationID, _ := ctx.Value(CtxKeyCausationID).(string)
+	return causationID
+}
+
+// WithCausationID sets the ID of the event or request being handled to the context
+func WithCausationID(ctx context.Context, causationID string) context.Context {
+	return context.WithValue(ctx, CtxKeyCausationID, causationID)
+}
+
 // GetClientID returns the OAuth client ID from the context.
 func GetClientID(ctx context.Context) uuid.UUID {
 	return ctx.Value(CtxKeyClientID).(uuid.UUID)
@@ -107,16 +119,6 @@ func WithAuthenticated(ctx context.Context, authenticated bool) context.Context
 	return context.WithValue(ctx, CtxKeyAuthenticated, authenticated)
 }
 
-// GetLogger returns the logger from the context.
-func GetLogger(ctx context.Context) *log.Entry {
-	return ctx.Value(CtxKeyLogger).(*log.Entry)
-}
-
-// WithLogger sets the logger to the context
-func WithLogger(ctx context.Context, logger *log.Entry) context.Context {
-	return context.WithValue(ctx, CtxKeyLogger, logger)
-}
-
 // GetScopes returns the OAuth scopes from the context.
 func GetScopes(ctx context.Context) Oauth2Scopes {
 	return ctx.Value(CtxKeyScopes).(Oauth2Scopes)
@@ -0,0 +1,119 @@
+package context
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetMode returns the name of the running mode from the context, if any.
+func GetMode(ctx context.Context) string {
+	mode, _ := ctx.Value(CtxKeyMode).(string)
+	return mode
+}
+
+// WithMode sets the name of the running mode to the context
+func WithMode(ctx context.Context, mode string) context.Context {
+	return context.WithValue(ctx, CtxKeyMode, mode)
+}
+
+// LogFields returns the fields propagated in the context to attach to log entries: correlation ID,
+// user ID, client ID, mode, and trace and span IDs. Fields absent from the context are omitted.
+func LogFields(ctx context.Context) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	if correlationID, ok := ctx.Value(CtxKeyCorrelationID).(string); ok && correlationID != "" {
+		fields["correlation_id"] = correlationID
+	}
+
+	if userID, ok := ctx.Value(CtxKeyUserID).(uuid.UUID); ok && userID != uuid.Nil {
+		fields["user_id"] = userID.String()
+	}
+
+	if clientID, ok := ctx.Value(CtxKeyClientID).(uuid.UUID); ok && clientID != uuid.Nil {
+		fields["client_id"] = clientID.String()
+	}
+
+	if mode := GetMode(ctx); mode != "" {
+		fields["mode"] = mode
+	}
+
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		fields["trace_id"] = spanCtx.TraceID().String()
+		fields["span_id"] = spanCtx.SpanID().String()
+	}
+
+	return fields
+}
+
+// GetLogger returns the logger from the context, with the context fields attached (see `LogFields`).
+// The default slog logger is used if the context holds no logger.
+func GetLogger(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(CtxKeyLogger).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+
+	fields := LogFields(ctx)
+	args := make([]any, 0, 2*len(fields))
+	for key, value := range fields {
+		args = append(args, key, value)
+	}
+
+	return logger.With(args...)
+}
+
+// WithLogger sets the logger to the context
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, CtxKeyLogger, logger)
+}
+
+// NewLogHandler returns a slog handler attaching the context fields (see `LogFields`) to the records
+// before passing them to the next handler. The fields already attached to the logger, e.g. by `GetLogger`,
+// are not attached twice.
+func NewLogHandler(next slog.Handler) slog.Handler {
+	return &logHandler{next: next}
+}
+
+type logHandler struct {
+	next     slog.Handler
+	attached map[string]bool
+	grouped  bool
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		for key, value := range LogFields(ctx) {
+			if !h.attached[key] {
+				record.AddAttrs(slog.Any(key, value))
+			}
+		}
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	attached := h.attached
+	if !h.grouped {
+		attached = make(map[string]bool, len(h.attached)+len(attrs))
+		for key := range h.attached {
+			attached[key] = true
+		}
+		for _, attr := range attrs {
+			attached[attr.Key] = true
+		}
+	}
+
+	return &logHandler{next: h.next.WithAttrs(attrs), attached: attached, grouped: h.grouped}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{next: h.next.WithGroup(name), attached: h.attached, grouped: h.grouped || name != ""}
+}
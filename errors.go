@@ -2,17 +2,24 @@ package foundation
 
 import (
 	"errors"
+	"fmt"
 
 	ferr "github.com/foundation-go/foundation/errors"
 	"github.com/getsentry/sentry-go"
 )
 
 func (s *Service) HandleError(err ferr.FoundationError, prefix string) {
+	// The errors of the handlers are already reported one by one, see `HandlersError`
+	var handlersError *HandlersError
+	if errors.As(err, &handlersError) {
+		return
+	}
+
 	// Log internal errors
 	var internalError *ferr.InternalError
 	if errors.As(err, &internalError) {
 		if prefix != "" {
-			s.Logger.Errorf("%s: %s", prefix, err.Error())
+			s.Logger.Error(fmt.Sprintf("%s: %s", prefix, err.Error()))
 		} else {
 			s.Logger.Error(err.Error())
 		}
@@ -2,7 +2,9 @@ package foundation
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
@@ -10,6 +12,7 @@ import (
 	fctx "github.com/foundation-go/foundation/context"
 	ferr "github.com/foundation-go/foundation/errors"
 	fkafka "github.com/foundation-go/foundation/kafka"
+	"github.com/getsentry/sentry-go"
 	"github.com/jackc/pgx/v5"
 	"github.com/segmentio/kafka-go"
 	"google.golang.org/protobuf/proto"
@@ -19,6 +22,9 @@ type EventsWorker struct {
 	*SpinWorker
 
 	protoNamesToMessages map[string]proto.Message
+	batchHandlers        map[string][]BatchEventHandler
+	retryPolicy          *RetryPolicy
+	retryDelays          []time.Duration
 }
 
 // EventHandler represents an event handler
@@ -36,10 +42,23 @@ const (
 	// ShutdownOnError stops the worker on error
 	ShutdownOnError
 
-	// RetryOnError retries to handle event on error. TODO: implement RetryOnError.
-	// RetryOnError
+	// RetryOnError retries to handle the event in place following the `RetryPolicy`, then republishes it to the
+	// retry topics, one after another, to be handled again once their delay has passed. The event is committed
+	// and skipped once the last retry topic is exhausted.
+	RetryOnError
 )
 
+const (
+	EventsWorkerDefaultMaxAttempts    = 3
+	EventsWorkerDefaultInitialBackoff = 100 * time.Millisecond
+	EventsWorkerDefaultMaxBackoff     = 2 * time.Second
+	EventsWorkerDefaultJitter         = 0.2
+)
+
+// EventsWorkerDefaultRetryDelays are the delays of the default retry topics, `<topic>.retry.1m` and
+// `<topic>.retry.10m`.
+var EventsWorkerDefaultRetryDelays = []time.Duration{time.Minute, 10 * time.Minute}
+
 // EventsWorkerOptions represents the options for starting an events worker
 type EventsWorkerOptions struct {
 	Handlers               map[proto.Message][]EventHandler
@@ -47,11 +66,53 @@ type EventsWorkerOptions struct {
 	ModeName               string
 	ErrorHandlingStrategy  ErrorHandlingStrategy
 	StartComponentsOptions []StartComponentsOption
+
+	// RetryPolicy describes how handling an event is retried in place with `RetryOnError`, before the event is
+	// republished to the retry topics, or by the handlers registered with `RetryOnFailure`. Default: 3 attempts,
+	// backoff from 100ms up to 2s with a 20% jitter.
+	RetryPolicy *RetryPolicy
+
+	// RetryDelays are the delays of the retry topics used with `RetryOnError`: an event republished to the
+	// `<topic>.retry.1m` topic is handled again a minute later, by its own consumer, so that the waiting events
+	// don't hold back the main topics. Default: 1m, 10m.
+	//
+	// The retry topics are created on the first retried event if the brokers allow it, create them beforehand
+	// otherwise.
+	RetryDelays []time.Duration
+
+	// Concurrency is the number of events handled at once. The events are kept in order by `Ordering`, and the
+	// offsets of each partition are only committed up to the highest contiguous handled one, so that no event is
+	// skipped after a crash. The retry topics are still handled one event at a time. Default: 1.
+	Concurrency int
+
+	// Ordering defines which events are handled one after another with `Concurrency`. Default: `OrderByKey`.
+	Ordering EventsOrdering
+
+	// BatchHandlers are the handlers receiving the events with the same proto at once, up to `BatchSize` events
+	// or the events received within `BatchTimeout` after the first one. Each batch is handled in one transaction
+	// once the `Handlers` have handled its events one at a time. A failed batch is split in halves handled
+	// separately, down to the events failing on their own, which are then handled with the `ErrorHandlingStrategy`
+	// without retries. The events of the retry topics are not handed to the batch handlers, and the worker
+	// terminates if `Concurrency` is set along with them.
+	BatchHandlers map[proto.Message][]BatchEventHandler
+
+	// BatchSize is the maximum number of events handled at once with `BatchHandlers`. Default: 100.
+	BatchSize int
+
+	// BatchTimeout is the time to wait for a batch to fill up with `BatchHandlers`. Default: 1s.
+	BatchTimeout time.Duration
 }
 
-func InitEventsWorker(name string) *EventsWorker {
+func InitEventsWorker(name string, opts ...InitOption) *EventsWorker {
 	return &EventsWorker{
-		SpinWorker: InitSpinWorker(name),
+		SpinWorker: InitSpinWorker(name, opts...),
+	}
+}
+
+// NewEventsWorker returns an events worker mode running on the given service, see `Service.StartModes`.
+func NewEventsWorker(s *Service) *EventsWorker {
+	return &EventsWorker{
+		SpinWorker: NewSpinWorker(s),
 	}
 }
 
@@ -64,11 +125,11 @@ func (opts *EventsWorkerOptions) GetTopics() []string {
 	// Otherwise, build topics from events we're handling
 	topics := []string{}
 
-	if len(opts.Handlers) == 0 {
+	if len(opts.Handlers) == 0 && len(opts.BatchHandlers) == 0 {
 		return nil
 	}
 
-	for protoMsg := range opts.Handlers {
+	for _, protoMsg := range opts.protoMessages() {
 		protoName := ProtoToName(protoMsg)
 		// Collect service names from event message names
 		// project.service.SomeEvent -> project.service
@@ -99,26 +160,107 @@ func (opts *EventsWorkerOptions) GetTopics() []string {
 func (opts *EventsWorkerOptions) ProtoNamesToMessages() map[string]proto.Message {
 	protoNamesToMessages := make(map[string]proto.Message)
 
-	for msg := range opts.Handlers {
-		protoNamesToMessages[ProtoToName(msg)] = msg
+	for _, msg := range opts.protoMessages() {
+		// The handlers are looked up by the message of the `Handlers`, if any
+		if _, ok := protoNamesToMessages[ProtoToName(msg)]; !ok {
+			protoNamesToMessages[ProtoToName(msg)] = msg
+		}
 	}
 
 	return protoNamesToMessages
 }
 
+// protoMessages returns the messages of the `Handlers`, then the ones of the `BatchHandlers`.
+func (opts *EventsWorkerOptions) protoMessages() []proto.Message {
+	msgs := make([]proto.Message, 0, len(opts.Handlers)+len(opts.BatchHandlers))
+
+	for msg := range opts.Handlers {
+		msgs = append(msgs, msg)
+	}
+
+	for msg := range opts.BatchHandlers {
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
 // Start runs the worker that handles events
 func (w *EventsWorker) Start(opts *EventsWorkerOptions) {
+	w.Service.Start(w.Mode(opts))
+}
+
+// Mode returns the events worker mode to run with `Service.StartModes`.
+func (w *EventsWorker) Mode(opts *EventsWorkerOptions) *StartOptions {
 	w.protoNamesToMessages = opts.ProtoNamesToMessages()
 
+	// The retry policy also applies to the handlers registered with `RetryOnFailure`
+	w.retryPolicy = opts.RetryPolicy
+	if w.retryPolicy == nil {
+		w.retryPolicy = &RetryPolicy{
+			Attempts:       EventsWorkerDefaultMaxAttempts,
+			InitialBackoff: EventsWorkerDefaultInitialBackoff,
+			MaxBackoff:     EventsWorkerDefaultMaxBackoff,
+			Jitter:         EventsWorkerDefaultJitter,
+		}
+	}
+
+	w.batchHandlers = make(map[string][]BatchEventHandler, len(opts.BatchHandlers))
+	for msg, handlers := range opts.BatchHandlers {
+		w.batchHandlers[ProtoToName(msg)] = handlers
+	}
+
+	if opts.ModeName == "" {
+		opts.ModeName = "events_worker"
+	}
+
 	wOpts := NewSpinWorkerOptions()
 	wOpts.ModeName = opts.ModeName
 	wOpts.ProcessFunc = w.newProcessEventFunc(opts.Handlers, opts.ErrorHandlingStrategy)
+
+	var dispatcher *eventsDispatcher
+	if opts.Concurrency > 1 {
+		dispatcher = w.newEventsDispatcher(opts.Handlers, opts.ErrorHandlingStrategy, opts.Concurrency, opts.Ordering)
+		wOpts.ProcessFunc = dispatcher.process
+		// Fetching is held back by the busy lanes instead
+		wOpts.Interval = 0
+	}
+	if len(opts.BatchHandlers) > 0 {
+		if opts.Concurrency > 1 {
+			logFatal(w.Logger, "`Concurrency` is not supported with `BatchHandlers`")
+		}
+
+		if opts.BatchSize <= 0 {
+			opts.BatchSize = EventsWorkerDefaultBatchSize
+		}
+
+		if opts.BatchTimeout <= 0 {
+			opts.BatchTimeout = EventsWorkerDefaultBatchTimeout
+		}
+
+		wOpts.ProcessFunc = w.newProcessBatchFunc(opts.Handlers, opts.ErrorHandlingStrategy, opts.BatchSize, opts.BatchTimeout)
+	}
 	wOpts.StartComponentsOptions = append(opts.StartComponentsOptions,
 		WithKafkaConsumer(),
 		WithKafkaConsumerTopics(opts.GetTopics()...),
 	)
 
-	w.SpinWorker.Start(wOpts)
+	if opts.ErrorHandlingStrategy != ShutdownOnError && w.Config.EventsWorker.DLQTopic != "" {
+		wOpts.StartComponentsOptions = append(wOpts.StartComponentsOptions, WithKafkaProducer())
+	}
+
+	var startOpts *StartOptions
+	if opts.ErrorHandlingStrategy == RetryOnError {
+		startOpts = w.retryMode(opts, wOpts)
+	} else {
+		startOpts = w.SpinWorker.Mode(wOpts)
+	}
+
+	if dispatcher != nil {
+		startOpts.ServiceFunc = dispatcher.waitLanes(startOpts.ServiceFunc)
+	}
+
+	return startOpts
 }
 
 func newEventFromKafkaMessage(msg *kafka.Message) *Event {
@@ -127,14 +269,7 @@ func newEventFromKafkaMessage(msg *kafka.Message) *Event {
 		headers[header.Key] = string(header.Value)
 	}
 
-	return &Event{
-		Topic:     msg.Topic,
-		Key:       string(msg.Key),
-		Payload:   msg.Value,
-		ProtoName: headers[fkafka.HeaderProtoName],
-		Headers:   headers,
-		CreatedAt: msg.Time,
-	}
+	return newEventFromHeaders(msg.Topic, string(msg.Key), msg.Value, headers, msg.Time)
 }
 
 func (w *EventsWorker) newProcessEventFunc(
@@ -142,74 +277,210 @@ func (w *EventsWorker) newProcessEventFunc(
 	errorMode ErrorHandlingStrategy,
 ) func(ctx context.Context) ferr.FoundationError {
 	return func(ctx context.Context) ferr.FoundationError {
-		msg, err := w.GetKafkaConsumer().FetchMessage(ctx)
+		consumer, fErr := w.LookupKafkaConsumer()
+		if fErr != nil {
+			return fErr
+		}
+
+		msg, err := consumer.FetchMessage(ctx)
 		if err != nil {
 			return ferr.NewInternalError(err, "failed to read message from Kafka")
 		}
 
-		event := newEventFromKafkaMessage(&msg)
+		return w.handleMessage(ctx, consumer, msg, handlers, errorMode)
+	}
+}
 
-		var handleErr ferr.FoundationError
+// handleMessage handles the event of the message read by the given consumer, and commits it.
+func (w *EventsWorker) handleMessage(
+	ctx context.Context,
+	consumer *kafka.Reader,
+	msg kafka.Message,
+	handlers map[proto.Message][]EventHandler,
+	errorMode ErrorHandlingStrategy,
+) ferr.FoundationError {
+	done, handleErr := w.handleEvent(ctx, msg, handlers, errorMode)
+	if done {
+		if commitErr := w.commitMessage(ctx, consumer, msg); commitErr != nil {
+			return commitErr
+		}
+	}
 
-		log := w.Logger.WithFields(map[string]interface{}{
-			"correlation_id": event.Headers[fkafka.HeaderCorrelationID],
-			"event":          event.ProtoName,
-		})
-		log.Info("Received event")
+	return handleErr
+}
 
-		templateProtoMsg, ok := w.protoNamesToMessages[event.ProtoName]
-		if !ok {
-			log.Debugf("Skip event without handlers: `%s`", event.ProtoName)
-			return nil
-		}
+// handleEvent handles the event of the message. It returns whether the message is done with and can be committed,
+// along with the handling error, if any.
+func (w *EventsWorker) handleEvent(
+	ctx context.Context,
+	msg kafka.Message,
+	handlers map[proto.Message][]EventHandler,
+	errorMode ErrorHandlingStrategy,
+) (bool, ferr.FoundationError) {
+	event, protoMsg, fErr := w.decodeEvent(&msg)
+	if fErr != nil {
+		return false, fErr
+	}
 
-		// Add explicit handlers
-		protoMsg := proto.Clone(templateProtoMsg)
-		curHandlers := handlers[templateProtoMsg]
-		err = proto.Unmarshal(event.Payload, protoMsg)
-		if err != nil {
-			return ferr.NewInternalError(err, "failed to unmarshal event payload")
-		}
+	// Skipped
+	if event == nil {
+		return true, nil
+	}
 
-		for _, handler := range curHandlers {
-			log := log.WithField("handler", fmt.Sprintf("%T", handler))
-			log.Info("Processing event")
+	return w.runHandlers(ctx, event, protoMsg, handlers[w.protoNamesToMessages[event.ProtoName]], errorMode)
+}
 
-			handleErr = w.processEvent(ctx, handler, event, protoMsg)
-			if handleErr != nil {
-				log.WithError(handleErr).Errorf("Failed to process event `%s`", event.ProtoName)
+// eventLogger returns the logger with the event fields.
+func (w *EventsWorker) eventLogger(event *Event) *slog.Logger {
+	return w.Logger.With(
+		"correlation_id", event.Headers[fkafka.HeaderCorrelationID],
+		"event", event.ProtoName,
+		"event_id", event.ID,
+	)
+}
 
-				// We publish the error event to the error topic for further delivery to the user via WebSocket.
-				if event.Headers[fkafka.HeaderOriginatorID] != "" {
-					err := w.NewAndPublishEvent(ctx, handleErr.MarshalProto(), event.Headers[fkafka.HeaderOriginatorID], nil, nil)
-					if err != nil {
-						return err
-					}
-				}
+// decodeEvent returns the event of the message along with its decoded payload, or a nil event if the event is
+// skipped by the worker.
+func (w *EventsWorker) decodeEvent(msg *kafka.Message) (*Event, proto.Message, ferr.FoundationError) {
+	event := newEventFromKafkaMessage(msg)
+
+	// Events read from the retry topics are handled as if they were read from their original topic
+	if topic := event.Headers[fkafka.HeaderOriginalTopic]; topic != "" {
+		event.Topic = topic
+	}
+
+	log := w.eventLogger(event)
+	log.Info("Received event")
 
-				// We just stop all the subsequent handlers from processing the event if one of them failed.
-				//
-				// TODO: Consider adding a configuration option to allow the user to choose whether to stop after
-				// specific handler failed or not. It would require to add ability to return multiple errors from
-				// this function.
+	// Events redriven from the dead-letter topic are only handled again by the service that failed to handle them
+	if service := event.Headers[fkafka.HeaderRedrivenFor]; service != "" && service != w.Name {
+		log.Debug(fmt.Sprintf("Skip event redriven for `%s`", service))
+		return nil, nil, nil
+	}
+
+	templateProtoMsg, ok := w.protoNamesToMessages[event.ProtoName]
+	if !ok {
+		log.Debug(fmt.Sprintf("Skip event without handlers: `%s`", event.ProtoName))
+		return nil, nil, nil
+	}
+
+	protoMsg := proto.Clone(templateProtoMsg)
+	if err := proto.Unmarshal(event.Payload, protoMsg); err != nil {
+		return nil, nil, ferr.NewInternalError(err, "failed to unmarshal event payload")
+	}
+
+	return event, protoMsg, nil
+}
+
+// runHandlers runs the handlers of the event one after another, following their failure policy. It returns whether
+// the event is done with, along with the `HandlersError` of the failed handlers, if any.
+func (w *EventsWorker) runHandlers(
+	ctx context.Context,
+	event *Event,
+	protoMsg proto.Message,
+	handlers []EventHandler,
+	errorMode ErrorHandlingStrategy,
+) (bool, ferr.FoundationError) {
+	log := w.eventLogger(event)
+
+	var failures []HandlerError
+
+	for _, handler := range retriedHandlers(handlers, event) {
+		log := log.With("handler", handlerName(handler))
+		log.Info("Processing event")
+
+		policy := failurePolicy(handler)
+
+		handleErr := w.processEvent(ctx, handler, event, protoMsg)
+		if handleErr != nil && (errorMode == RetryOnError || policy == RetryOnFailure) {
+			var republished bool
+			if republished, handleErr = w.retryEvent(ctx, handler, event, protoMsg, handleErr); republished {
+				log.Info("Event republished to the retry topic")
+
+				// With `ContinueOnFailure`, the subsequent handlers run now, otherwise once the event is handled
+				// from the retry topic
+				if policy == ContinueOnFailure {
+					continue
+				}
 				break
 			}
+		}
 
+		if handleErr == nil {
 			log.Info("Event processed successfully")
+			continue
 		}
 
-		if handleErr != nil && errorMode == ShutdownOnError {
-			w.Logger.WithField("event", event.ProtoName).Errorf("Cannot process event: %v", handleErr)
-			w.cancelFunc()
-			return handleErr
+		done, fErr := w.failEvent(ctx, event, handler, handleErr, w.handleAttempts(event, handler, errorMode), errorMode)
+		if fErr != nil {
+			return false, fErr
 		}
 
-		if commitErr := w.CommitMessage(ctx, msg); commitErr != nil {
-			return commitErr
+		failures = append(failures, HandlerError{Handler: handlerName(handler), Err: handleErr})
+		if !done {
+			return false, &HandlersError{Errors: failures}
 		}
 
-		return handleErr
+		if policy != ContinueOnFailure {
+			break
+		}
+	}
+
+	if len(failures) > 0 {
+		return true, &HandlersError{Errors: failures}
+	}
+
+	return true, nil
+}
+
+// failEvent reports the failure of the handler to Sentry and to the originator of the event, then stops the worker
+// with `ShutdownOnError`, or publishes the event to the dead-letter topic. It returns whether the event is done with,
+// along with the error of reporting the failure, if any.
+func (w *EventsWorker) failEvent(
+	ctx context.Context,
+	event *Event,
+	handler interface{},
+	handleErr ferr.FoundationError,
+	attempts int,
+	errorMode ErrorHandlingStrategy,
+) (bool, ferr.FoundationError) {
+	w.eventLogger(event).Error(fmt.Sprintf("Failed to process event `%s`", event.ProtoName),
+		"handler", handlerName(handler), "error", handleErr)
+
+	// Each failed handler is reported on its own, along with the event
+	var internalError *ferr.InternalError
+	if errors.As(handleErr, &internalError) {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetTags(map[string]string{
+			"event":    event.ProtoName,
+			"event_id": event.ID,
+			"handler":  handlerName(handler),
+		})
+		hub.CaptureException(handleErr)
+	}
+
+	// We publish the error event to the error topic for further delivery to the user via WebSocket.
+	if event.Headers[fkafka.HeaderOriginatorID] != "" {
+		err := w.NewAndPublishEvent(ctx, handleErr.MarshalProto(), event.Headers[fkafka.HeaderOriginatorID], nil, nil)
+		if err != nil {
+			return false, err
+		}
 	}
+
+	if errorMode == ShutdownOnError {
+		w.Logger.Error(fmt.Sprintf("Cannot process event: %v", handleErr), "event", event.ProtoName)
+		w.triggerShutdown()
+		return false, nil
+	}
+
+	// The event is skipped, keep it in the dead-letter topic to be inspected and redriven
+	if w.Config.EventsWorker.DLQTopic != "" {
+		if dlqErr := w.deadLetterEvent(ctx, event, handler, handleErr, attempts); dlqErr != nil {
+			return false, dlqErr
+		}
+	}
+
+	return true, nil
 }
 
 func (w *EventsWorker) processEvent(ctx context.Context, handler EventHandler, event *Event, msg proto.Message) ferr.FoundationError {
@@ -220,7 +491,12 @@ func (w *EventsWorker) processEvent(ctx context.Context, handler EventHandler, e
 	)
 
 	if w.Config.Database.Enabled {
-		tx, err = w.GetPostgreSQL().Begin(ctx)
+		pool, fErr := w.LookupPostgreSQL()
+		if fErr != nil {
+			return fErr
+		}
+
+		tx, err = pool.Begin(ctx)
 		if err != nil {
 			return ferr.NewInternalError(err, "failed to begin transaction")
 		}
@@ -233,6 +509,8 @@ func (w *EventsWorker) processEvent(ctx context.Context, handler EventHandler, e
 
 	// Add correlation ID to context
 	ctx = fctx.WithCorrelationID(ctx, event.Headers[fkafka.HeaderCorrelationID])
+	// The events published while handling the event are caused by it
+	ctx = fctx.WithCausationID(ctx, event.ID)
 
 	// Handle event
 	events, handleErr := handler.Handle(ctx, event, msg)
@@ -242,6 +520,10 @@ func (w *EventsWorker) processEvent(ctx context.Context, handler EventHandler, e
 
 	// Publish outgoing events
 	for _, e := range events {
+		if e.CausationID == "" {
+			e.CausationID = event.ID
+		}
+
 		if publishErr := w.PublishEvent(ctx, e, tx); publishErr != nil {
 			return publishErr
 		}
@@ -261,9 +543,19 @@ func (w *EventsWorker) processEvent(ctx context.Context, handler EventHandler, e
 // If the commit operation fails, it retries up to three times with a one-second pause between retries.
 // If all attempts fail, the function returns the last occurred error.
 func (s *Service) CommitMessage(ctx context.Context, msg kafka.Message) ferr.FoundationError {
+	consumer, fErr := s.LookupKafkaConsumer()
+	if fErr != nil {
+		return fErr
+	}
+
+	return s.commitMessage(ctx, consumer, msg)
+}
+
+// commitMessage commits Kafka messages using the given consumer, see `CommitMessage`.
+func (s *Service) commitMessage(ctx context.Context, consumer *kafka.Reader, msgs ...kafka.Message) ferr.FoundationError {
 	// TODO: Make something clever here, like exponential backoff
 	for i := 0; i < 3; i++ {
-		err := s.GetKafkaConsumer().CommitMessages(ctx, msg)
+		err := consumer.CommitMessages(ctx, msgs...)
 		if err == nil {
 			return nil
 		}
@@ -0,0 +1,232 @@
+package foundation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+
+	fctx "github.com/foundation-go/foundation/context"
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+const (
+	EventsWorkerDefaultBatchSize    = 100
+	EventsWorkerDefaultBatchTimeout = time.Second
+)
+
+// BatchEventHandler represents an event handler handling several events at once, see
+// `EventsWorkerOptions.BatchHandlers`.
+type BatchEventHandler interface {
+	HandleBatch(context.Context, []*DecodedEvent) ([]*Event, ferr.FoundationError)
+}
+
+// DecodedEvent represents an event along with its decoded payload
+type DecodedEvent struct {
+	*Event
+
+	Message proto.Message
+}
+
+// newProcessBatchFunc returns the process function fetching up to `batchSize` messages, or the messages fetched
+// within `batchTimeout` after the first one, and handling them at once.
+func (w *EventsWorker) newProcessBatchFunc(
+	handlers map[proto.Message][]EventHandler,
+	errorMode ErrorHandlingStrategy,
+	batchSize int,
+	batchTimeout time.Duration,
+) func(ctx context.Context) ferr.FoundationError {
+	return func(ctx context.Context) ferr.FoundationError {
+		consumer, fErr := w.LookupKafkaConsumer()
+		if fErr != nil {
+			return fErr
+		}
+
+		msg, err := consumer.FetchMessage(ctx)
+		if err != nil {
+			return ferr.NewInternalError(err, "failed to read message from Kafka")
+		}
+
+		msgs := []kafka.Message{msg}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, batchTimeout)
+		for len(msgs) < batchSize {
+			// The next iteration reports the errors other than the timeout
+			if msg, err = consumer.FetchMessage(fetchCtx); err != nil {
+				break
+			}
+			msgs = append(msgs, msg)
+		}
+		cancel()
+
+		// The messages after an event stopping the worker are handled again after a restart
+		handled := w.handleMessages(ctx, msgs, handlers, errorMode)
+		if handled == 0 {
+			return nil
+		}
+
+		return w.commitMessage(ctx, consumer, msgs[:handled]...)
+	}
+}
+
+// handleMessages handles the events of the messages with the handlers one event at a time, then with the batch
+// handlers, the events with the same proto at once. The failures are reported as they occur, and the failed events
+// are skipped as when the events are handled one by one, except with `ShutdownOnError`: then the first event not
+// done with stops the worker, and the batch handlers only handle the events before it. It returns the number of
+// messages to commit, the ones before such an event.
+func (w *EventsWorker) handleMessages(
+	ctx context.Context,
+	msgs []kafka.Message,
+	handlers map[proto.Message][]EventHandler,
+	errorMode ErrorHandlingStrategy,
+) int {
+	var (
+		protoNames []string
+		batches    = make(map[string][]*DecodedEvent)
+		positions  = make(map[*DecodedEvent]int)
+
+		handled = len(msgs)
+	)
+
+	for i := range msgs {
+		event, protoMsg, fErr := w.decodeEvent(&msgs[i])
+		if fErr != nil {
+			w.HandleError(fErr, "failed to process event")
+			continue
+		}
+
+		// Skipped
+		if event == nil {
+			continue
+		}
+
+		done, fErr := w.runHandlers(ctx, event, protoMsg, handlers[w.protoNamesToMessages[event.ProtoName]], errorMode)
+		if fErr != nil {
+			w.HandleError(fErr, "failed to process event")
+		}
+
+		if !done && errorMode == ShutdownOnError {
+			handled = i
+			break
+		}
+
+		// The batch handlers are not run on the events failed with the other handlers
+		if !done || fErr != nil {
+			continue
+		}
+
+		decoded := &DecodedEvent{Event: event, Message: protoMsg}
+		positions[decoded] = i
+
+		if _, ok := batches[event.ProtoName]; !ok {
+			protoNames = append(protoNames, event.ProtoName)
+		}
+		batches[event.ProtoName] = append(batches[event.ProtoName], decoded)
+	}
+
+	for _, protoName := range protoNames {
+		for _, handler := range w.batchHandlers[protoName] {
+			for _, failure := range w.runBatchHandler(ctx, handler, batches[protoName]) {
+				done, fErr := w.failEvent(ctx, failure.event.Event, handler, failure.err, 1, errorMode)
+				if fErr != nil {
+					w.HandleError(fErr, "failed to process event")
+				}
+
+				if !done && errorMode == ShutdownOnError {
+					handled = min(handled, positions[failure.event])
+				}
+			}
+		}
+	}
+
+	return handled
+}
+
+type batchFailure struct {
+	event *DecodedEvent
+	err   ferr.FoundationError
+}
+
+// runBatchHandler runs the batch handler on the events. On failure, the events are split in halves, handled
+// separately, down to the events failing on their own. It returns these events with their errors.
+func (w *EventsWorker) runBatchHandler(ctx context.Context, handler BatchEventHandler, events []*DecodedEvent) []batchFailure {
+	log := w.Logger.With("handler", handlerName(handler))
+	log.Info(fmt.Sprintf("Processing %d events", len(events)))
+
+	handleErr := w.processBatch(ctx, handler, events)
+	if handleErr == nil {
+		log.Info(fmt.Sprintf("%d events processed successfully", len(events)))
+		return nil
+	}
+
+	if len(events) == 1 {
+		return []batchFailure{{event: events[0], err: handleErr}}
+	}
+
+	log.Warn(fmt.Sprintf("Failed to process %d events, bisecting", len(events)), "error", handleErr)
+
+	half := len(events) / 2
+
+	return append(w.runBatchHandler(ctx, handler, events[:half]), w.runBatchHandler(ctx, handler, events[half:])...)
+}
+
+// processBatch runs the batch handler on the events in one transaction, along with publishing the returned events.
+func (w *EventsWorker) processBatch(ctx context.Context, handler BatchEventHandler, events []*DecodedEvent) ferr.FoundationError {
+	var (
+		tx         pgx.Tx
+		needCommit bool
+		err        error
+	)
+
+	if w.Config.Database.Enabled {
+		pool, fErr := w.LookupPostgreSQL()
+		if fErr != nil {
+			return fErr
+		}
+
+		tx, err = pool.Begin(ctx)
+		if err != nil {
+			return ferr.NewInternalError(err, "failed to begin transaction")
+		}
+		defer tx.Rollback(ctx) // nolint:errcheck
+		needCommit = true
+
+		// Add transaction to context
+		ctx = fctx.WithTX(ctx, tx)
+	}
+
+	// The batch is correlated with and the cause of the published events only if it holds a single event
+	var correlationID, causationID string
+	if len(events) == 1 {
+		correlationID = events[0].Headers[fkafka.HeaderCorrelationID]
+		causationID = events[0].ID
+	}
+	ctx = fctx.WithCorrelationID(ctx, correlationID)
+	ctx = fctx.WithCausationID(ctx, causationID)
+
+	// Handle events
+	outgoing, handleErr := handler.HandleBatch(ctx, events)
+	if handleErr != nil {
+		return handleErr
+	}
+
+	// Publish outgoing events
+	for _, e := range outgoing {
+		if publishErr := w.PublishEvent(ctx, e, tx); publishErr != nil {
+			return publishErr
+		}
+	}
+
+	if needCommit {
+		// Commit transaction
+		if err = tx.Commit(ctx); err != nil {
+			return ferr.NewInternalError(err, "failed to commit transaction")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+// poisonedBatchHandler fails the batches holding any of the poisoned events
+type poisonedBatchHandler struct {
+	poisoned map[string]bool
+	batches  int
+	handled  []string
+}
+
+func (h *poisonedBatchHandler) HandleBatch(_ context.Context, events []*DecodedEvent) ([]*Event, ferr.FoundationError) {
+	h.batches++
+
+	for _, event := range events {
+		if h.poisoned[event.ID] {
+			return nil, ferr.NewInternalError(errors.New("invalid payload"), "failed to handle events")
+		}
+	}
+
+	for _, event := range events {
+		h.handled = append(h.handled, event.ID)
+	}
+
+	return nil, nil
+}
+
+func TestRunBatchHandler(t *testing.T) {
+	w := newTestEventsWorker()
+
+	var events []*DecodedEvent
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		events = append(events, &DecodedEvent{Event: &Event{ID: id, Headers: map[string]string{}}})
+	}
+
+	handler := &poisonedBatchHandler{poisoned: map[string]bool{}}
+	if failures := w.runBatchHandler(context.Background(), handler, events); len(failures) != 0 {
+		t.Errorf("Expected no failures, but got %d", len(failures))
+	}
+	if handler.batches != 1 || len(handler.handled) != 8 {
+		t.Errorf("Expected the events to be handled in 1 batch, but got %d batches", handler.batches)
+	}
+
+	handler = &poisonedBatchHandler{poisoned: map[string]bool{"3": true}}
+	failures := w.runBatchHandler(context.Background(), handler, events)
+	if len(failures) != 1 || failures[0].event.ID != "3" || failures[0].err == nil {
+		t.Fatalf("Expected event 3 to fail, but got %v", failures)
+	}
+
+	// 1-8, 1-4, 1-2, 3-4, 3, 4, 5-8
+	if handler.batches != 7 {
+		t.Errorf("Expected 7 batches, but got %d", handler.batches)
+	}
+	if len(handler.handled) != 7 {
+		t.Errorf("Expected all the other events to be handled, but got %v", handler.handled)
+	}
+}
+
+func TestHandleMessages(t *testing.T) {
+	w := newTestEventsWorker()
+
+	protoName := ProtoToName(&wrapperspb.StringValue{})
+	w.protoNamesToMessages = map[string]proto.Message{protoName: &wrapperspb.StringValue{}}
+
+	var msgs []kafka.Message
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		payload, _ := proto.Marshal(&wrapperspb.StringValue{Value: id})
+		// An invalid payload in the middle of the batch
+		if id == "3" {
+			payload = []byte{0xff}
+		}
+
+		msgs = append(msgs, kafka.Message{Topic: "chats", Offset: int64(len(msgs)), Value: payload, Headers: []kafka.Header{
+			{Key: fkafka.HeaderProtoName, Value: []byte(protoName)},
+			{Key: fkafka.HeaderEventID, Value: []byte(id)},
+		}})
+	}
+
+	// The event failed to decode is skipped, the other ones are handled and all the messages are committed
+	handler := &poisonedBatchHandler{poisoned: map[string]bool{}}
+	w.batchHandlers = map[string][]BatchEventHandler{protoName: {handler}}
+	if handled := w.handleMessages(context.Background(), msgs, nil, IgnoreError); handled != 5 {
+		t.Errorf("Expected all the messages to be committed, but got %d", handled)
+	}
+	if expected := []string{"1", "2", "4", "5"}; !reflect.DeepEqual(handler.handled, expected) {
+		t.Errorf("Expected events %v to be handled, but got %v", expected, handler.handled)
+	}
+
+	// With `ShutdownOnError`, only the messages before the failed event are committed
+	handler = &poisonedBatchHandler{poisoned: map[string]bool{"4": true}}
+	w.batchHandlers = map[string][]BatchEventHandler{protoName: {handler}}
+	if handled := w.handleMessages(context.Background(), msgs, nil, ShutdownOnError); handled != 3 {
+		t.Errorf("Expected the 3 messages before the failed event to be committed, but got %d", handled)
+	}
+}
@@ -0,0 +1,290 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+
+	ferr "github.com/foundation-go/foundation/errors"
+)
+
+// EventsOrdering defines which events are kept in order when the events worker handles events concurrently.
+type EventsOrdering int
+
+const (
+	// OrderByKey handles the events with the same key one after another. The events without a key are handled in
+	// any order. Default.
+	OrderByKey EventsOrdering = iota
+
+	// OrderByPartition handles the events of the same partition one after another.
+	OrderByPartition
+)
+
+// eventsDispatcher dispatches the fetched messages to the lanes handling them concurrently. The messages that must
+// be kept in order are dispatched to the same lane.
+type eventsDispatcher struct {
+	worker    *EventsWorker
+	handlers  map[proto.Message][]EventHandler
+	errorMode ErrorHandlingStrategy
+	ordering  EventsOrdering
+
+	lanes   []chan laneMessage
+	next    atomic.Uint64
+	started sync.Once
+	running sync.WaitGroup
+
+	offsets  *offsetTracker
+	commitMu sync.Mutex
+}
+
+// newEventsDispatcher returns the dispatcher of the fetched messages to `concurrency` lanes, see
+// `EventsWorkerOptions.Concurrency`.
+func (w *EventsWorker) newEventsDispatcher(
+	handlers map[proto.Message][]EventHandler,
+	errorMode ErrorHandlingStrategy,
+	concurrency int,
+	ordering EventsOrdering,
+) *eventsDispatcher {
+	d := &eventsDispatcher{
+		worker:    w,
+		handlers:  handlers,
+		errorMode: errorMode,
+		ordering:  ordering,
+		lanes:     make([]chan laneMessage, concurrency),
+		offsets:   newOffsetTracker(),
+	}
+
+	for i := range d.lanes {
+		d.lanes[i] = make(chan laneMessage, 1)
+	}
+
+	return d
+}
+
+// process fetches a message and dispatches it to its lane, starting the lanes on the first call.
+func (d *eventsDispatcher) process(ctx context.Context) ferr.FoundationError {
+	d.started.Do(func() {
+		d.running.Add(len(d.lanes))
+		for _, lane := range d.lanes {
+			go func(lane chan laneMessage) {
+				defer d.running.Done()
+				d.run(ctx, lane)
+			}(lane)
+		}
+	})
+
+	consumer, fErr := d.worker.LookupKafkaConsumer()
+	if fErr != nil {
+		return fErr
+	}
+
+	msg, err := consumer.FetchMessage(ctx)
+	if err != nil {
+		return ferr.NewInternalError(err, "failed to read message from Kafka")
+	}
+
+	tracked := d.offsets.fetched(msg)
+
+	// Blocks while the lane is busy, so that the fetched messages don't pile up
+	select {
+	case d.lanes[d.lane(msg)] <- laneMessage{msg: msg, tracked: tracked}:
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+// waitLanes returns the service function waiting for the lanes to be done with their current message once the
+// given one returns, so that the components are not stopped while the handlers are still running.
+func (d *eventsDispatcher) waitLanes(serviceFunc func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := serviceFunc(ctx)
+		d.running.Wait()
+
+		return err
+	}
+}
+
+// lane returns the index of the lane to handle the message on.
+func (d *eventsDispatcher) lane(msg kafka.Message) int {
+	hash := fnv.New32a()
+
+	switch {
+	case d.ordering == OrderByPartition:
+		_, _ = fmt.Fprintf(hash, "%s:%d", msg.Topic, msg.Partition)
+	case len(msg.Key) > 0:
+		_, _ = hash.Write(msg.Key)
+	default:
+		return int(d.next.Add(1) % uint64(len(d.lanes)))
+	}
+
+	return int(hash.Sum32() % uint32(len(d.lanes)))
+}
+
+// laneMessage is a message dispatched to a lane, along with its tracked offset.
+type laneMessage struct {
+	msg     kafka.Message
+	tracked *trackedOffset
+}
+
+// run handles the messages dispatched to the lane until the context is done.
+func (d *eventsDispatcher) run(ctx context.Context, lane chan laneMessage) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dispatched := <-lane:
+			d.handle(ctx, dispatched)
+		}
+	}
+}
+
+// handle handles the event of the dispatched message, then commits the messages of its partition handled so far.
+func (d *eventsDispatcher) handle(ctx context.Context, dispatched laneMessage) {
+	msg := dispatched.msg
+
+	done, fErr := d.worker.handleEvent(ctx, msg, d.handlers, d.errorMode)
+	if fErr != nil {
+		d.worker.HandleError(fErr, "failed to process event")
+	}
+
+	// A message not done with because of a failed handler with `ShutdownOnError`, which stopped the worker, or
+	// because the worker is stopping, holds back the commits of its partition, so that it is handled again after
+	// a restart
+	var handlersErr *HandlersError
+	if !done && (d.errorMode == ShutdownOnError && errors.As(fErr, &handlersErr) || ctx.Err() != nil) {
+		return
+	}
+
+	// Otherwise, as when the messages are handled one by one, it is not committed itself, but the commits of the
+	// later messages of its partition move past it
+	commitMsg, ok := d.offsets.done(msg, dispatched.tracked)
+	if !ok || !done && commitMsg.Offset == msg.Offset {
+		return
+	}
+
+	if fErr = d.commit(ctx, commitMsg); fErr != nil {
+		d.worker.HandleError(fErr, "failed to commit message")
+	}
+}
+
+// commit commits the message, unless a later message of its partition is already committed.
+func (d *eventsDispatcher) commit(ctx context.Context, msg kafka.Message) ferr.FoundationError {
+	d.commitMu.Lock()
+	defer d.commitMu.Unlock()
+
+	if !d.offsets.commitNeeded(msg) {
+		return nil
+	}
+
+	consumer, fErr := d.worker.LookupKafkaConsumer()
+	if fErr != nil {
+		return fErr
+	}
+
+	if fErr = d.worker.commitMessage(ctx, consumer, msg); fErr != nil {
+		return fErr
+	}
+
+	d.offsets.committed(msg)
+
+	return nil
+}
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+type trackedOffset struct {
+	offset int64
+	done   bool
+}
+
+// offsetTracker tracks the messages handled concurrently, so that the offsets of each partition are only committed
+// up to the highest contiguous handled one.
+type offsetTracker struct {
+	mu          sync.Mutex
+	pending     map[topicPartition][]*trackedOffset
+	lastFetched map[topicPartition]int64
+	lastCommit  map[topicPartition]int64
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending:     make(map[topicPartition][]*trackedOffset),
+		lastFetched: make(map[topicPartition]int64),
+		lastCommit:  make(map[topicPartition]int64),
+	}
+}
+
+// fetched tracks the fetched message and returns its tracked offset. The messages of a partition are fetched in the
+// order of their offsets, unless the partition was revoked on a rebalance, then assigned again and fetched from the
+// committed offset: the state of the partition is reset then, the messages still tracked are handled by the
+// previous assignment.
+func (t *offsetTracker) fetched(msg kafka.Message) *trackedOffset {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tp := topicPartition{msg.Topic, msg.Partition}
+
+	if last, ok := t.lastFetched[tp]; ok && msg.Offset <= last {
+		delete(t.pending, tp)
+		delete(t.lastCommit, tp)
+	}
+	t.lastFetched[tp] = msg.Offset
+
+	tracked := &trackedOffset{offset: msg.Offset}
+	t.pending[tp] = append(t.pending[tp], tracked)
+
+	return tracked
+}
+
+// done marks the message as handled. It returns the message to commit, the last one of the handled messages of the
+// partition fetched before any unhandled one, if any.
+func (t *offsetTracker) done(msg kafka.Message, tracked *trackedOffset) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Not tracked anymore if the partition was reset in the meantime, see `fetched`
+	tracked.done = true
+
+	tp := topicPartition{msg.Topic, msg.Partition}
+	pending := t.pending[tp]
+
+	var last *trackedOffset
+	for len(pending) > 0 && pending[0].done {
+		last, pending = pending[0], pending[1:]
+	}
+	t.pending[tp] = pending
+
+	if last == nil {
+		return kafka.Message{}, false
+	}
+
+	return kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: last.offset}, true
+}
+
+// commitNeeded returns whether the message is later than the last committed one of its partition.
+func (t *offsetTracker) commitNeeded(msg kafka.Message) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.lastCommit[topicPartition{msg.Topic, msg.Partition}]
+
+	return !ok || msg.Offset > last
+}
+
+// committed records the message as the last committed one of its partition.
+func (t *offsetTracker) committed(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastCommit[topicPartition{msg.Topic, msg.Partition}] = msg.Offset
+}
@@ -0,0 +1,230 @@
+package foundation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	messages := make([]kafka.Message, 4)
+	tracked := make([]*trackedOffset, 4)
+	for i := range messages {
+		messages[i] = kafka.Message{Topic: "chats", Partition: 1, Offset: int64(10 + i)}
+		tracked[i] = tracker.fetched(messages[i])
+	}
+	other := kafka.Message{Topic: "chats", Partition: 2, Offset: 5}
+	otherTracked := tracker.fetched(other)
+
+	// Handled out of order: nothing to commit until the first one is handled
+	for _, i := range []int{1, 3} {
+		if _, ok := tracker.done(messages[i], tracked[i]); ok {
+			t.Errorf("Expected no commit after handling offset %d", messages[i].Offset)
+		}
+	}
+
+	commitMsg, ok := tracker.done(messages[0], tracked[0])
+	if !ok || commitMsg.Offset != 11 || commitMsg.Partition != 1 {
+		t.Errorf("Expected offset 11 of partition 1 to be committed, but got %+v", commitMsg)
+	}
+
+	commitMsg, ok = tracker.done(messages[2], tracked[2])
+	if !ok || commitMsg.Offset != 13 {
+		t.Errorf("Expected offset 13 to be committed, but got %+v", commitMsg)
+	}
+
+	// Partitions are tracked separately
+	if commitMsg, ok = tracker.done(other, otherTracked); !ok || commitMsg.Offset != 5 || commitMsg.Partition != 2 {
+		t.Errorf("Expected offset 5 of partition 2 to be committed, but got %+v", commitMsg)
+	}
+
+	tracker.committed(kafka.Message{Topic: "chats", Partition: 1, Offset: 13})
+	if tracker.commitNeeded(kafka.Message{Topic: "chats", Partition: 1, Offset: 11}) {
+		t.Error("Expected an earlier offset not to be committed")
+	}
+}
+
+func TestOffsetTrackerMessageNotDone(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	failed := kafka.Message{Topic: "chats", Partition: 1, Offset: 10}
+	failedTracked := tracker.fetched(failed)
+	next := kafka.Message{Topic: "chats", Partition: 1, Offset: 11}
+	nextTracked := tracker.fetched(next)
+
+	// The next message is held back while the failed one is handled
+	if _, ok := tracker.done(next, nextTracked); ok {
+		t.Error("Expected no commit before the failed message is handled")
+	}
+
+	// The message not done with is released as the lanes do, and the commit moves past it
+	if commitMsg, ok := tracker.done(failed, failedTracked); !ok || commitMsg.Offset != 11 {
+		t.Errorf("Expected offset 11 to be committed past the failed message, but got %+v", commitMsg)
+	}
+}
+
+func TestOffsetTrackerRebalance(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	first := kafka.Message{Topic: "chats", Partition: 1, Offset: 9}
+	if commitMsg, ok := tracker.done(first, tracker.fetched(first)); ok {
+		tracker.committed(commitMsg)
+	}
+	stale := kafka.Message{Topic: "chats", Partition: 1, Offset: 10}
+	staleTracked := tracker.fetched(stale)
+
+	// Revoked while offset 10 is handled, then assigned again and fetched from offset 9, as its commit was lost
+	refetched := kafka.Message{Topic: "chats", Partition: 1, Offset: 9}
+	refetchedTracked := tracker.fetched(refetched)
+
+	if _, ok := tracker.done(stale, staleTracked); ok {
+		t.Error("Expected no commit for the message of the previous assignment")
+	}
+
+	commitMsg, ok := tracker.done(refetched, refetchedTracked)
+	if !ok || commitMsg.Offset != 9 {
+		t.Errorf("Expected offset 9 to be committed, but got %+v", commitMsg)
+	}
+	if !tracker.commitNeeded(commitMsg) {
+		t.Error("Expected the commits of the previous assignment to be reset")
+	}
+}
+
+func TestEventsDispatcherLane(t *testing.T) {
+	d := &eventsDispatcher{lanes: make([]chan laneMessage, 4)}
+
+	lane := d.lane(kafka.Message{Topic: "chats", Partition: 0, Key: []byte("42")})
+	for partition := 1; partition < 10; partition++ {
+		if got := d.lane(kafka.Message{Topic: "chats", Partition: partition, Key: []byte("42")}); got != lane {
+			t.Errorf("Expected the events with the same key to be handled on lane %d, but got %d", lane, got)
+		}
+	}
+
+	d.ordering = OrderByPartition
+	lane = d.lane(kafka.Message{Topic: "chats", Partition: 3, Key: []byte("1")})
+	for _, key := range []string{"2", "3", ""} {
+		if got := d.lane(kafka.Message{Topic: "chats", Partition: 3, Key: []byte(key)}); got != lane {
+			t.Errorf("Expected the events of the same partition to be handled on lane %d, but got %d", lane, got)
+		}
+	}
+}
+
+// newTestShutdownEventsWorker returns an events worker handling `StringValue` events with the given handler,
+// along with whether it triggered its shutdown.
+func newTestShutdownEventsWorker(handler EventHandler) (*EventsWorker, map[proto.Message][]EventHandler, *bool) {
+	w := newTestEventsWorker()
+
+	shutdown := false
+	w.cancelFunc = func() { shutdown = true }
+
+	template := &wrapperspb.StringValue{}
+	w.protoNamesToMessages = map[string]proto.Message{ProtoToName(template): template}
+
+	return w, map[proto.Message][]EventHandler{template: {handler}}, &shutdown
+}
+
+// newTestStringValueMessage returns a message of a `StringValue` event with the given payload.
+func newTestStringValueMessage(offset int64, payload []byte) kafka.Message {
+	return kafka.Message{Topic: "chats", Partition: 1, Offset: offset, Value: payload, Headers: []kafka.Header{
+		{Key: fkafka.HeaderProtoName, Value: []byte(ProtoToName(&wrapperspb.StringValue{}))},
+	}}
+}
+
+func TestHandleMessageShutdownOnError(t *testing.T) {
+	payload, _ := proto.Marshal(&wrapperspb.StringValue{Value: "hello"})
+
+	// A message failed to decode is reported and skipped, as with the other strategies
+	w, handlers, shutdown := newTestShutdownEventsWorker(&flakyHandler{failures: 10})
+	if err := w.handleMessage(context.Background(), nil, newTestStringValueMessage(10, []byte{0xff}), handlers, ShutdownOnError); err == nil || *shutdown {
+		t.Errorf("Expected a decoding error without shutdown, but got %v, shutdown: %v", err, *shutdown)
+	}
+
+	// A failed handler stops the worker
+	if err := w.handleMessage(context.Background(), nil, newTestStringValueMessage(11, payload), handlers, ShutdownOnError); err == nil || !*shutdown {
+		t.Errorf("Expected a handler error with shutdown, but got %v, shutdown: %v", err, *shutdown)
+	}
+}
+
+func TestEventsDispatcherShutdownOnError(t *testing.T) {
+	payload, _ := proto.Marshal(&wrapperspb.StringValue{Value: "hello"})
+
+	w, handlers, shutdown := newTestShutdownEventsWorker(&flakyHandler{failures: 10})
+	d := w.newEventsDispatcher(handlers, ShutdownOnError, 2, OrderByKey)
+	tp := topicPartition{"chats", 1}
+
+	// A message failed to decode is released as when the messages are handled one by one
+	undecodable := newTestStringValueMessage(10, []byte{0xff})
+	d.handle(context.Background(), laneMessage{msg: undecodable, tracked: d.offsets.fetched(undecodable)})
+	if *shutdown || len(d.offsets.pending[tp]) != 0 {
+		t.Errorf("Expected the message to be released without shutdown, but got %d pending, shutdown: %v", len(d.offsets.pending[tp]), *shutdown)
+	}
+
+	// A failed handler stops the worker and holds back the commits of the partition
+	failed := newTestStringValueMessage(11, payload)
+	d.handle(context.Background(), laneMessage{msg: failed, tracked: d.offsets.fetched(failed)})
+	if !*shutdown || len(d.offsets.pending[tp]) != 1 {
+		t.Errorf("Expected the message to be held back with shutdown, but got %d pending, shutdown: %v", len(d.offsets.pending[tp]), *shutdown)
+	}
+}
+
+func TestEventsDispatcherWaitLanes(t *testing.T) {
+	handler := &blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	w, handlers, _ := newTestShutdownEventsWorker(handler)
+	d := w.newEventsDispatcher(handlers, IgnoreError, 2, OrderByKey)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Starts the lanes, then stops at fetching without a consumer
+	_ = d.process(ctx)
+
+	payload, _ := proto.Marshal(&wrapperspb.StringValue{Value: "hello"})
+	msg := newTestStringValueMessage(10, payload)
+	d.lanes[0] <- laneMessage{msg: msg, tracked: d.offsets.fetched(msg)}
+	<-handler.started
+
+	stopped := make(chan struct{})
+	go func() {
+		_ = d.waitLanes(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})(ctx)
+		close(stopped)
+	}()
+
+	cancel()
+
+	select {
+	case <-stopped:
+		t.Fatal("Expected the service function to wait for the running handler")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(handler.release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("Expected the service function to return once the handler is done")
+	}
+}
+
+// blockingHandler blocks until released.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(context.Context, *Event, proto.Message) ([]*Event, ferr.FoundationError) {
+	close(h.started)
+	<-h.release
+
+	return nil, nil
+}
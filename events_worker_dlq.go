@@ -0,0 +1,101 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+// deadLetterEvent publishes the event that failed to be handled to the `EVENTS_WORKER_DLQ_TOPIC`, with the
+// original headers and the failure details.
+func (w *EventsWorker) deadLetterEvent(
+	ctx context.Context,
+	event *Event,
+	handler interface{},
+	handleErr ferr.FoundationError,
+	attempts int,
+) ferr.FoundationError {
+	headers := maps.Clone(event.Headers)
+	headers[fkafka.HeaderOriginalTopic] = event.Topic
+	headers[fkafka.HeaderDLQAttempts] = strconv.Itoa(attempts)
+	headers[fkafka.HeaderDLQError] = handleErr.Error()
+	headers[fkafka.HeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+	headers[fkafka.HeaderDLQHandler] = handlerName(handler)
+	headers[fkafka.HeaderDLQService] = w.Name
+
+	dead := *event
+	dead.Topic = w.Config.EventsWorker.DLQTopic
+	dead.Headers = headers
+
+	message, err := NewMessageFromEvent(&dead)
+	if err != nil {
+		return ferr.NewInternalError(err, "failed to create message from event")
+	}
+
+	producer, fErr := w.LookupKafkaBatchProducer()
+	if fErr != nil {
+		return fErr
+	}
+
+	if err = producer.WriteMessages(ctx, *message); err != nil {
+		return ferr.NewInternalError(err, "failed to publish event to the dead-letter topic")
+	}
+
+	return nil
+}
+
+// handleAttempts returns the number of times the handler failed to handle the event with the error handling strategy
+// and its failure policy.
+func (w *EventsWorker) handleAttempts(event *Event, handler EventHandler, errorMode ErrorHandlingStrategy) int {
+	if errorMode != RetryOnError {
+		if failurePolicy(handler) == RetryOnFailure {
+			return max(w.retryPolicy.Attempts, 1)
+		}
+
+		return 1
+	}
+
+	retryAttempt, _ := strconv.Atoi(event.Headers[fkafka.HeaderRetryAttempt])
+
+	return (retryAttempt + 1) * max(w.retryPolicy.Attempts, 1)
+}
+
+// NewRedriveMessage returns the message to publish to redrive a message from the dead-letter topic: the original
+// event, sent back to its source topic, to be handled again by the service that failed to handle it only.
+func NewRedriveMessage(msg kafka.Message) (kafka.Message, error) {
+	var topic, service string
+	headers := make([]kafka.Header, 0, len(msg.Headers)+1)
+
+	for _, header := range msg.Headers {
+		switch {
+		case header.Key == fkafka.HeaderOriginalTopic:
+			topic = string(header.Value)
+		case header.Key == fkafka.HeaderDLQService:
+			service = string(header.Value)
+		case strings.HasPrefix(header.Key, "dlq-"), strings.HasPrefix(header.Key, "retry-"),
+			header.Key == fkafka.HeaderRedrivenFor:
+			// Failure details, not part of the original event
+		default:
+			headers = append(headers, header)
+		}
+	}
+
+	if topic == "" || service == "" {
+		return kafka.Message{}, errors.New("not a dead-lettered message")
+	}
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: append(headers, kafka.Header{Key: fkafka.HeaderRedrivenFor, Value: []byte(service)}),
+	}, nil
+}
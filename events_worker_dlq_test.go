@@ -0,0 +1,67 @@
+package foundation
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+func TestNewRedriveMessage(t *testing.T) {
+	msg := kafka.Message{
+		Topic: "foundation.events_worker.dlq",
+		Key:   []byte("42"),
+		Value: []byte("payload"),
+		Headers: []kafka.Header{
+			{Key: fkafka.HeaderProtoName, Value: []byte("chats.MessageSent")},
+			{Key: fkafka.HeaderOriginalTopic, Value: []byte("chats")},
+			{Key: fkafka.HeaderRetryAttempt, Value: []byte("2")},
+			{Key: fkafka.HeaderDLQService, Value: []byte("notifications")},
+			{Key: fkafka.HeaderDLQError, Value: []byte("deadlock detected")},
+		},
+	}
+
+	redriven, err := NewRedriveMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if redriven.Topic != "chats" || string(redriven.Key) != "42" || string(redriven.Value) != "payload" {
+		t.Errorf("Expected the original event on the `chats` topic, but got %+v", redriven)
+	}
+
+	expected := []kafka.Header{
+		{Key: fkafka.HeaderProtoName, Value: []byte("chats.MessageSent")},
+		{Key: fkafka.HeaderRedrivenFor, Value: []byte("notifications")},
+	}
+	if len(redriven.Headers) != len(expected) {
+		t.Fatalf("Expected headers %v, but got %v", expected, redriven.Headers)
+	}
+	for i, header := range expected {
+		if redriven.Headers[i].Key != header.Key || string(redriven.Headers[i].Value) != string(header.Value) {
+			t.Errorf("Expected header %v, but got %v", header, redriven.Headers[i])
+		}
+	}
+
+	if _, err = NewRedriveMessage(kafka.Message{Topic: "chats"}); err == nil {
+		t.Error("Expected an error for a message not read from the dead-letter topic")
+	}
+}
+
+func TestHandleAttempts(t *testing.T) {
+	w := &EventsWorker{retryPolicy: &RetryPolicy{Attempts: 3}}
+	event := &Event{Headers: map[string]string{fkafka.HeaderRetryAttempt: "2"}}
+
+	if attempts := w.handleAttempts(event, &flakyHandler{}, IgnoreError); attempts != 1 {
+		t.Errorf("Expected 1 attempt, but got %d", attempts)
+	}
+
+	if attempts := w.handleAttempts(event, WithFailurePolicy(&flakyHandler{}, RetryOnFailure), IgnoreError); attempts != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", attempts)
+	}
+
+	if attempts := w.handleAttempts(event, &flakyHandler{}, RetryOnError); attempts != 9 {
+		t.Errorf("Expected 9 attempts, but got %d", attempts)
+	}
+}
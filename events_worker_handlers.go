@@ -0,0 +1,101 @@
+package foundation
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	pb "github.com/foundation-go/foundation/errors/proto"
+)
+
+// HandlerFailurePolicy defines what happens to the subsequent handlers of an event when a handler fails to
+// handle it. Each handler is run in its own transaction, so the failure doesn't roll back the other handlers.
+type HandlerFailurePolicy int
+
+const (
+	// StopOnFailure skips the subsequent handlers of the event. Default.
+	StopOnFailure HandlerFailurePolicy = iota
+
+	// ContinueOnFailure runs the subsequent handlers of the event anyway.
+	ContinueOnFailure
+
+	// RetryOnFailure retries the handler in place following `EventsWorkerOptions.RetryPolicy`, whatever the
+	// `ErrorHandlingStrategy`, then skips the subsequent handlers of the event if it still fails.
+	RetryOnFailure
+)
+
+// policyHandler is an event handler registered with a failure policy
+type policyHandler struct {
+	EventHandler
+
+	policy HandlerFailurePolicy
+}
+
+// WithFailurePolicy returns the handler to register with the given failure policy, e.g.:
+//
+//	Handlers: map[proto.Message][]f.EventHandler{
+//		&pb.MessageSent{}: {
+//			f.WithFailurePolicy(&NotifyMembers{}, f.ContinueOnFailure),
+//			&UpdateChatStats{},
+//		},
+//	}
+func WithFailurePolicy(handler EventHandler, policy HandlerFailurePolicy) EventHandler {
+	return &policyHandler{EventHandler: handler, policy: policy}
+}
+
+// failurePolicy returns the failure policy the handler was registered with.
+func failurePolicy(handler EventHandler) HandlerFailurePolicy {
+	if h, ok := handler.(*policyHandler); ok {
+		return h.policy
+	}
+
+	return StopOnFailure
+}
+
+// HandlerError is the error of a handler that failed to handle an event
+type HandlerError struct {
+	Handler string
+	Err     ferr.FoundationError
+}
+
+// HandlersError is the error of the handlers that failed to handle an event. The errors are reported one by one
+// as they occur, so it is not reported again.
+type HandlersError struct {
+	Errors []HandlerError
+}
+
+func (e *HandlersError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, handlerErr := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", handlerErr.Handler, handlerErr.Err.Error()))
+	}
+
+	return "failed to handle event: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the handlers, so that they can be matched with `errors.Is` and `errors.As`.
+func (e *HandlersError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, handlerErr := range e.Errors {
+		errs = append(errs, handlerErr.Err)
+	}
+
+	return errs
+}
+
+func (e *HandlersError) GRPCStatus() *status.Status {
+	return status.New(codes.Internal, "internal error")
+}
+
+// MarshalProto marshals the error to a proto.Message.
+func (e *HandlersError) MarshalProto() proto.Message {
+	return &pb.InternalError{}
+}
+
+func (e *HandlersError) MarshalJSON() ([]byte, error) {
+	return []byte("{}"), nil
+}
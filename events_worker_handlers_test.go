@@ -0,0 +1,64 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+func TestRunHandlersFailurePolicy(t *testing.T) {
+	w := newTestEventsWorker()
+	event := &Event{ID: "1", ProtoName: "chats.MessageSent", Headers: map[string]string{}}
+
+	// The subsequent handlers are skipped by default
+	failing, next := &flakyHandler{failures: 10}, &otherHandler{}
+	done, err := w.runHandlers(context.Background(), event, nil, []EventHandler{failing, next}, IgnoreError)
+	if !done || err == nil {
+		t.Errorf("Expected the event to be done with an error, but got %v, %v", done, err)
+	}
+	if next.attempts != 0 {
+		t.Errorf("Expected the next handler to be skipped, but it ran %d times", next.attempts)
+	}
+
+	// The subsequent handlers run anyway, and all the failures are returned
+	failing, last := &flakyHandler{failures: 10}, &otherHandler{flakyHandler{failures: 10}}
+	handlers := []EventHandler{WithFailurePolicy(failing, ContinueOnFailure), last}
+	_, err = w.runHandlers(context.Background(), event, nil, handlers, IgnoreError)
+
+	var handlersErr *HandlersError
+	if !errors.As(err, &handlersErr) || len(handlersErr.Errors) != 2 {
+		t.Fatalf("Expected the errors of both handlers, but got %v", err)
+	}
+	if handlersErr.Errors[0].Handler != handlerName(failing) || handlersErr.Errors[1].Handler != handlerName(last) {
+		t.Errorf("Expected the errors in the order of the handlers, but got %v", handlersErr.Errors)
+	}
+
+	var internalErr *ferr.InternalError
+	if !errors.As(err, &internalErr) {
+		t.Errorf("Expected the errors of the handlers to be unwrapped, but got %v", err)
+	}
+
+	// The handler is retried in place, even with `IgnoreError`
+	flaky, next := &flakyHandler{failures: 2}, &otherHandler{}
+	handlers = []EventHandler{WithFailurePolicy(flaky, RetryOnFailure), next}
+	if _, err = w.runHandlers(context.Background(), event, nil, handlers, IgnoreError); err != nil {
+		t.Errorf("Expected the handler to succeed on the last attempt, but got %v", err)
+	}
+	if flaky.attempts != 3 || next.attempts != 1 {
+		t.Errorf("Expected 3 attempts and the next handler to run, but got %d and %d", flaky.attempts, next.attempts)
+	}
+}
+
+func TestRetriedHandlersContinueOnFailure(t *testing.T) {
+	first, second := &flakyHandler{}, &otherHandler{}
+	handlers := []EventHandler{WithFailurePolicy(first, ContinueOnFailure), second}
+
+	// The subsequent handlers already ran when the event was republished
+	event := &Event{Headers: map[string]string{fkafka.HeaderRetryHandler: handlerName(first)}}
+	if got := retriedHandlers(handlers, event); len(got) != 1 || got[0] != handlers[0] {
+		t.Errorf("Expected only the failed handler to run, but got %v", got)
+	}
+}
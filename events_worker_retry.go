@@ -0,0 +1,222 @@
+package foundation
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+// retryMode returns the events worker mode with `RetryOnError`, consuming the retry topics along with the main ones.
+func (w *EventsWorker) retryMode(opts *EventsWorkerOptions, wOpts *SpinWorkerOptions) *StartOptions {
+	w.retryDelays = opts.RetryDelays
+	if w.retryDelays == nil {
+		w.retryDelays = EventsWorkerDefaultRetryDelays
+	}
+
+	suffixes := make([]string, 0, len(w.retryDelays))
+	for _, delay := range w.retryDelays {
+		suffixes = append(suffixes, retryTopicSuffix(delay))
+	}
+
+	wOpts.StartComponentsOptions = append(wOpts.StartComponentsOptions,
+		WithKafkaConsumerRetryTopics(suffixes...),
+		WithKafkaProducer(),
+	)
+
+	startOpts := w.SpinWorker.Mode(wOpts)
+
+	serviceFunc := startOpts.ServiceFunc
+	startOpts.ServiceFunc = func(ctx context.Context) error {
+		for _, suffix := range suffixes {
+			go w.consumeRetryTopics(ctx, suffix, opts.Handlers)
+		}
+
+		return serviceFunc(ctx)
+	}
+
+	return startOpts
+}
+
+// retryTopicSuffix returns the suffix of the retry topics with the given delay, e.g. `.retry.10m`.
+func retryTopicSuffix(delay time.Duration) string {
+	switch {
+	case delay%time.Hour == 0:
+		return fmt.Sprintf(".retry.%dh", delay/time.Hour)
+	case delay%time.Minute == 0:
+		return fmt.Sprintf(".retry.%dm", delay/time.Minute)
+	default:
+		return fmt.Sprintf(".retry.%ds", delay/time.Second)
+	}
+}
+
+// handlerName returns the name the handler is identified by in the logs and the event headers.
+func handlerName(handler interface{}) string {
+	if h, ok := handler.(*policyHandler); ok {
+		return handlerName(h.EventHandler)
+	}
+
+	return fmt.Sprintf("%T", handler)
+}
+
+// retriedHandlers returns the handlers to run for the event: for an event read from a retry topic, the handler
+// that failed and the subsequent ones, unless these already ran with `ContinueOnFailure`.
+func retriedHandlers(handlers []EventHandler, event *Event) []EventHandler {
+	name := event.Headers[fkafka.HeaderRetryHandler]
+	if name == "" {
+		return handlers
+	}
+
+	for i, handler := range handlers {
+		if handlerName(handler) == name {
+			if failurePolicy(handler) == ContinueOnFailure {
+				return handlers[i : i+1]
+			}
+
+			return handlers[i:]
+		}
+	}
+
+	return handlers
+}
+
+// retryEvent retries handling the event in place following the retry policy, then republishes the event to the
+// next retry topic. It returns whether the event was republished, or the last error once the retry topics are
+// exhausted.
+func (w *EventsWorker) retryEvent(
+	ctx context.Context,
+	handler EventHandler,
+	event *Event,
+	msg proto.Message,
+	handleErr ferr.FoundationError,
+) (bool, ferr.FoundationError) {
+	log := w.Logger.With(
+		"event", event.ProtoName,
+		"event_id", event.ID,
+		"handler", handlerName(handler),
+	)
+
+	for attempt := 2; attempt <= w.retryPolicy.Attempts; attempt++ {
+		delay := w.retryPolicy.backoff(attempt)
+		log.Warn(fmt.Sprintf("Retrying event in %s (attempt %d of %d)", delay, attempt, w.retryPolicy.Attempts), "error", handleErr)
+
+		select {
+		case <-ctx.Done():
+			return false, handleErr
+		case <-time.After(delay):
+		}
+
+		if handleErr = w.processEvent(ctx, handler, event, msg); handleErr == nil {
+			return false, nil
+		}
+	}
+
+	retryAttempt, _ := strconv.Atoi(event.Headers[fkafka.HeaderRetryAttempt])
+	if retryAttempt >= len(w.retryDelays) {
+		return false, handleErr
+	}
+
+	if err := w.republishEvent(ctx, event, handler, handleErr, retryAttempt+1); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// republishEvent publishes the event to the retry topic of the given (1-based) retry attempt.
+func (w *EventsWorker) republishEvent(
+	ctx context.Context,
+	event *Event,
+	handler EventHandler,
+	handleErr ferr.FoundationError,
+	retryAttempt int,
+) ferr.FoundationError {
+	delay := w.retryDelays[retryAttempt-1]
+
+	headers := maps.Clone(event.Headers)
+	headers[fkafka.HeaderOriginalTopic] = event.Topic
+	headers[fkafka.HeaderRetryAttempt] = strconv.Itoa(retryAttempt)
+	headers[fkafka.HeaderRetryError] = handleErr.Error()
+	headers[fkafka.HeaderRetryHandler] = handlerName(handler)
+	headers[fkafka.HeaderRetryNotBefore] = time.Now().Add(delay).UTC().Format(time.RFC3339Nano)
+
+	retried := *event
+	retried.Topic = event.Topic + retryTopicSuffix(delay)
+	retried.Headers = headers
+
+	message, err := NewMessageFromEvent(&retried)
+	if err != nil {
+		return ferr.NewInternalError(err, "failed to create message from event")
+	}
+
+	producer, fErr := w.LookupKafkaBatchProducer()
+	if fErr != nil {
+		return fErr
+	}
+
+	if err = producer.WriteMessages(ctx, *message); err != nil {
+		return ferr.NewInternalError(err, "failed to publish event to the retry topic")
+	}
+
+	return nil
+}
+
+// consumeRetryTopics handles the events of the retry topics with the given suffix once their delay has passed.
+// The events are republished to a retry topic in the order of their delay, so waiting for the first one
+// doesn't hold back the others.
+func (w *EventsWorker) consumeRetryTopics(ctx context.Context, suffix string, handlers map[proto.Message][]EventHandler) {
+	consumer, fErr := w.LookupKafkaRetryConsumer(suffix)
+	if fErr != nil {
+		w.HandleError(fErr, "failed to consume retry topics")
+		return
+	}
+
+	for w.waitResumed(ctx) {
+		msg, err := consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			w.HandleError(ferr.NewInternalError(err, "failed to read message from Kafka"), "failed to process iteration")
+			continue
+		}
+
+		if !waitRetryNotBefore(ctx, &msg) {
+			return
+		}
+
+		if fErr = w.handleMessage(ctx, consumer, msg, handlers, RetryOnError); fErr != nil {
+			w.HandleError(fErr, "failed to process iteration")
+		}
+	}
+}
+
+// waitRetryNotBefore blocks until the message can be retried. It returns false if the context is done meanwhile.
+func waitRetryNotBefore(ctx context.Context, msg *kafka.Message) bool {
+	for _, header := range msg.Headers {
+		if header.Key != fkafka.HeaderRetryNotBefore {
+			continue
+		}
+
+		notBefore, err := time.Parse(time.RFC3339Nano, string(header.Value))
+		if err != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Until(notBefore)):
+		}
+	}
+
+	return ctx.Err() == nil
+}
@@ -0,0 +1,94 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+// newTestEventsWorker returns an events worker retrying the events in place 3 times, without any component.
+func newTestEventsWorker() *EventsWorker {
+	return &EventsWorker{
+		SpinWorker: &SpinWorker{Service: &Service{
+			Config: &Config{Database: &DatabaseConfig{}, EventsWorker: &EventsWorkerConfig{}},
+			Logger: initLogger("test", nil, new(slog.LevelVar)),
+		}},
+		retryPolicy: &RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond},
+	}
+}
+
+type flakyHandler struct {
+	failures int
+	attempts int
+}
+
+func (h *flakyHandler) Handle(context.Context, *Event, proto.Message) ([]*Event, ferr.FoundationError) {
+	h.attempts++
+
+	if h.attempts <= h.failures {
+		return nil, ferr.NewInternalError(errors.New("deadlock detected"), "failed to handle event")
+	}
+
+	return nil, nil
+}
+
+type otherHandler struct {
+	flakyHandler
+}
+
+func TestRetryTopicSuffix(t *testing.T) {
+	for delay, expected := range map[time.Duration]string{
+		time.Minute:      ".retry.1m",
+		10 * time.Minute: ".retry.10m",
+		2 * time.Hour:    ".retry.2h",
+		90 * time.Second: ".retry.90s",
+	} {
+		if suffix := retryTopicSuffix(delay); suffix != expected {
+			t.Errorf("Expected suffix %s for %s, but got %s", expected, delay, suffix)
+		}
+	}
+}
+
+func TestRetriedHandlers(t *testing.T) {
+	first, second := &flakyHandler{}, &otherHandler{}
+	handlers := []EventHandler{first, second}
+
+	if got := retriedHandlers(handlers, &Event{Headers: map[string]string{}}); len(got) != 2 {
+		t.Errorf("Expected all the handlers to run, but got %d", len(got))
+	}
+
+	event := &Event{Headers: map[string]string{fkafka.HeaderRetryHandler: handlerName(second)}}
+	if got := retriedHandlers(handlers, event); len(got) != 1 || got[0] != second {
+		t.Errorf("Expected only the failed handler to run, but got %v", got)
+	}
+}
+
+func TestRetryEventInPlace(t *testing.T) {
+	w := newTestEventsWorker()
+	event := &Event{ID: "1", Headers: map[string]string{}}
+
+	// Fails once more, then succeeds on the last attempt
+	handler := &flakyHandler{failures: 1}
+	republished, err := w.retryEvent(context.Background(), handler, event, nil, ferr.NewInternalError(errors.New("deadlock detected"), "failed to handle event"))
+	if err != nil || republished {
+		t.Errorf("Expected the event to be handled in place, but got %v", err)
+	}
+
+	if handler.attempts != 2 {
+		t.Errorf("Expected 2 more attempts, but got %d", handler.attempts)
+	}
+
+	// Without retry topics left, the last error is returned
+	handler = &flakyHandler{failures: 2}
+	republished, err = w.retryEvent(context.Background(), handler, event, nil, ferr.NewInternalError(errors.New("deadlock detected"), "failed to handle event"))
+	if err == nil || republished {
+		t.Error("Expected the event to fail")
+	}
+}
@@ -2,17 +2,23 @@ package foundation
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/getsentry/sentry-go"
-	"github.com/sirupsen/logrus"
 
+	fctx "github.com/foundation-go/foundation/context"
+	fhydra "github.com/foundation-go/foundation/hydra"
 	fjobs "github.com/foundation-go/foundation/jobs"
 	fkafka "github.com/foundation-go/foundation/kafka"
+	"github.com/foundation-go/foundation/outboxrepo"
 	fpg "github.com/foundation-go/foundation/postgresql"
 	fredis "github.com/foundation-go/foundation/redis"
 	fsentry "github.com/foundation-go/foundation/sentry"
@@ -29,7 +35,30 @@ type Service struct {
 	ModeName   string
 	cancelFunc context.CancelFunc
 
-	Logger *logrus.Entry
+	// AppConfig is the application configuration bound with `WithAppConfig`
+	AppConfig interface{}
+
+	parent                      *Service
+	componentGraph              *componentGraph
+	componentsByName            map[string]Component
+	componentsStarted           atomic.Bool
+	shuttingDown                atomic.Bool
+	shutdownCtx                 context.Context
+	onStartHooks                []LifecycleHook
+	onShutdownHooks             []LifecycleHook
+	systemComponentNames        []string
+	startRetryPolicy            *RetryPolicy
+	componentStartRetryPolicies map[string]*RetryPolicy
+	configLoader                *ConfigLoader
+	secretRotationHandlers      map[string][]SecretRotationHandler
+	secretRotationMu            sync.RWMutex
+	stopSecretRotation          func()
+	adminToken                  atomic.Value
+	workers                     map[string]*SpinWorker
+	logHandler                  slog.Handler
+	logLevel                    slog.LevelVar
+
+	Logger *slog.Logger
 }
 
 // Config represents the configuration of a Service.
@@ -43,6 +72,16 @@ type Config struct {
 	Redis        *RedisConfig
 	Sentry       *SentryConfig
 	JobsEnqueuer *JobsEnqueuerConfig
+	Shutdown     *ShutdownConfig
+	Secrets      *SecretsConfig
+	Admin        *AdminConfig
+	Server       *ServerConfig
+	Log          *LogConfig
+	Cable        *CableConfig
+	Hydra        *HydraConfig
+
+	// loadErr holds the errors occurred while loading the configuration
+	loadErr error
 }
 
 // DatabaseConfig represents the configuration of a PostgreSQL database.
@@ -50,6 +89,9 @@ type DatabaseConfig struct {
 	Enabled bool
 	Pool    int
 	URL     string
+
+	// AutoMigrate applies the framework migrations at startup, see `WithAutoMigrate`.
+	AutoMigrate bool
 }
 
 // EventsWorkerConfig represents the configuration of an event bus.
@@ -62,6 +104,11 @@ type EventsWorkerConfig struct {
 	// should be published to the errors topic (and thus, delivered
 	// to originator, aka user) or not.
 	DeliverErrors bool
+
+	// DLQTopic is the name of the Kafka topic to which the events that failed
+	// to be handled are published, see `foundation events:dlq`. Empty to
+	// skip the failed events.
+	DLQTopic string
 }
 
 // GRPCConfig represents the configuration of a gRPC server.
@@ -89,6 +136,9 @@ type KafkaSASLConfig struct {
 type KafkaConsumerConfig struct {
 	Enabled bool
 	Topics  []string
+
+	// RetryTopicSuffixes are the suffixes of the retry topics to consume, see `WithKafkaConsumerRetryTopics`.
+	RetryTopicSuffixes []string
 }
 
 // KafkaProducerConfig represents the configuration of a Kafka producer.
@@ -102,6 +152,9 @@ type KafkaProducerConfig struct {
 type MetricsConfig struct {
 	Enabled bool
 	Port    int
+
+	// HealthCheckTimeout is the timeout of a single component health check, in seconds.
+	HealthCheckTimeout int
 }
 
 // SentryConfig represents the configuration of a Sentry client.
@@ -113,6 +166,43 @@ type SentryConfig struct {
 // OutboxConfig represents the configuration of an outbox.
 type OutboxConfig struct {
 	Enabled bool
+
+	// Notify enables the notifications of the outbox couriers on new events, see `OutboxCourier`.
+	Notify bool
+
+	// Retention is the time to keep the published events for in seconds, see `OutboxCourier`.
+	// Zero deletes the events once published.
+	Retention int
+
+	// Schema is the PostgreSQL schema of the outbox tables. Empty uses the search path of the connection.
+	Schema string
+	// Table is the name of the outbox events table.
+	Table string
+	// DeadTable is the name of the dead outbox events table.
+	DeadTable string
+	// Partitioning is the time-based partitioning of the events table by `created_at`, `daily` or `monthly`.
+	// Empty disables the partitioning.
+	Partitioning string
+}
+
+// Tables returns the outbox tables qualified with the schema.
+func (c *OutboxConfig) Tables() outboxrepo.Tables {
+	return outboxrepo.Tables{Events: c.qualify(c.Table), DeadEvents: c.qualify(c.DeadTable)}
+}
+
+// NotifyChannel returns the PostgreSQL channel notified on new outbox events, see `OUTBOX_NOTIFY`. It is named
+// after the events table, so the couriers of different outboxes sharing a database are not woken up by each other.
+func (c *OutboxConfig) NotifyChannel() string {
+	return c.qualify(c.Table)
+}
+
+// qualify qualifies the name of a table in the outbox schema.
+func (c *OutboxConfig) qualify(name string) string {
+	if c.Schema == "" {
+		return name
+	}
+
+	return c.Schema + "." + name
 }
 
 // RedisConfig represents the configuration of a Redis client.
@@ -129,27 +219,82 @@ type JobsEnqueuerConfig struct {
 	Namespace string
 }
 
-// NewConfig returns a new Config with values populated from environment variables.
+// ShutdownConfig represents the configuration of the service graceful shutdown.
+type ShutdownConfig struct {
+	// DrainDelay is the time to wait after the service is marked as not ready and before
+	// the servers are stopped, in seconds.
+	DrainDelay int
+
+	// Timeout is the deadline for stopping the servers and components, in seconds. The process
+	// is forcibly terminated once it is exceeded.
+	Timeout int
+}
+
+// AdminConfig represents the configuration of the admin API.
+type AdminConfig struct {
+	Enabled bool
+	// Token is the bearer token authenticating the admin API requests.
+	Token string
+}
+
+// ServerConfig represents the configuration of the server-based running modes.
+type ServerConfig struct {
+	// Port is the port to listen on.
+	Port int
+}
+
+// LogConfig represents the configuration of the logger.
+type LogConfig struct {
+	// Level is the minimum level of the logged entries, e.g. `info`.
+	Level string
+}
+
+// CableConfig represents the configuration of the cable courier.
+type CableConfig struct {
+	// RedisChannel is the Redis channel the AnyCable broadcasts are published to.
+	RedisChannel string
+}
+
+// HydraConfig represents the configuration of the Hydra authentication provider.
+type HydraConfig struct {
+	// AdminURL is the URL of the Hydra Admin API.
+	AdminURL string
+}
+
+// SecretsConfig represents the configuration of the secrets rotation.
+type SecretsConfig struct {
+	// RotationInterval is the interval at which secrets are re-read, in seconds. Zero disables rotation.
+	RotationInterval int
+}
+
+// NewConfig returns a new Config with values populated from the configuration files and environment
+// variables (see `ConfigLoader`). Loading errors are reported by `Config.Validate`.
 func NewConfig() *Config {
-	return &Config{
+	return NewConfigFrom(NewConfigLoader())
+}
+
+// NewConfigFrom returns a new Config with values resolved by the given loader.
+func NewConfigFrom(l *ConfigLoader) *Config {
+	c := &Config{
 		Database: &DatabaseConfig{
-			Enabled: len(GetEnvOrString("DATABASE_URL", "")) > 0,
-			Pool:    GetEnvOrInt("DATABASE_POOL", 5),
-			URL:     GetEnvOrString("DATABASE_URL", ""),
+			Enabled: len(l.String("DATABASE_URL", "")) > 0,
+			Pool:    l.Int("DATABASE_POOL", 5),
+			URL:     l.String("DATABASE_URL", ""),
 		},
 		EventsWorker: &EventsWorkerConfig{
-			ErrorsTopic:   GetEnvOrString("EVENTS_WORKER_ERRORS_TOPIC", "foundation.events_worker.errors"),
-			DeliverErrors: GetEnvOrBool("EVENTS_WORKER_DELIVER_ERRORS", true),
+			ErrorsTopic:   l.String("EVENTS_WORKER_ERRORS_TOPIC", "foundation.events_worker.errors"),
+			DeliverErrors: l.Bool("EVENTS_WORKER_DELIVER_ERRORS", true),
+			DLQTopic:      l.String("EVENTS_WORKER_DLQ_TOPIC", "foundation.events_worker.dlq"),
 		},
 		GRPC: &GRPCConfig{
-			TLSDir: GetEnvOrString("GRPC_TLS_DIR", ""),
+			TLSDir: l.String("GRPC_TLS_DIR", ""),
 		},
 		Kafka: &KafkaConfig{
-			Brokers: strings.Split(GetEnvOrString("KAFKA_BROKERS", ""), ","),
+			Brokers: l.Strings("KAFKA_BROKERS", nil),
 			SASL: &KafkaSASLConfig{
-				Username: GetEnvOrString("KAFKA_SASL_USERNAME", ""),
-				Password: GetEnvOrString("KAFKA_SASL_PASSWORD", ""),
-				Protocol: GetEnvOrString("KAFKA_SASL_PROTOCOL", ""),
+				Username: l.String("KAFKA_SASL_USERNAME", ""),
+				Password: l.String("KAFKA_SASL_PASSWORD", ""),
+				Protocol: l.String("KAFKA_SASL_PROTOCOL", ""),
 			},
 			Consumer: &KafkaConsumerConfig{
 				Enabled: false,
@@ -157,42 +302,109 @@ func NewConfig() *Config {
 			},
 			Producer: &KafkaProducerConfig{
 				Enabled:      false,
-				BatchSize:    GetEnvOrInt("KAFKA_PRODUCER_BATCH_SIZE", 1),
-				BatchTimeout: GetEnvOrInt("KAFKA_PRODUCER_BATCH_TIMEOUT", 1),
+				BatchSize:    l.Int("KAFKA_PRODUCER_BATCH_SIZE", 1),
+				BatchTimeout: l.Int("KAFKA_PRODUCER_BATCH_TIMEOUT", 1),
 			},
-			TLSDir: GetEnvOrString("KAFKA_TLS_DIR", ""),
+			TLSDir: l.String("KAFKA_TLS_DIR", ""),
 		},
 		Metrics: &MetricsConfig{
-			Enabled: GetEnvOrBool("METRICS_ENABLED", true),
-			Port:    GetEnvOrInt("METRICS_PORT", 51077),
+			Enabled: l.Bool("METRICS_ENABLED", true),
+			Port:    l.Int("METRICS_PORT", 51077),
+
+			HealthCheckTimeout: l.Int("HEALTH_CHECK_TIMEOUT", MetricsDefaultHealthCheckTimeout),
 		},
 		Outbox: &OutboxConfig{
 			Enabled: false,
+			Notify:  l.Bool("OUTBOX_NOTIFY", false),
+
+			Retention: l.Int("OUTBOX_RETENTION", 0),
+
+			Schema:       l.String("OUTBOX_SCHEMA", ""),
+			Table:        l.String("OUTBOX_TABLE", outboxrepo.DefaultEventsTable),
+			DeadTable:    l.String("OUTBOX_DEAD_TABLE", outboxrepo.DefaultDeadEventsTable),
+			Partitioning: l.String("OUTBOX_PARTITIONING", ""),
 		},
 		Redis: &RedisConfig{
-			Enabled: len(GetEnvOrString("REDIS_URL", "")) > 0,
-			URL:     GetEnvOrString("REDIS_URL", ""),
+			Enabled: len(l.String("REDIS_URL", "")) > 0,
+			URL:     l.String("REDIS_URL", ""),
 		},
 		Sentry: &SentryConfig{
-			DSN:     GetEnvOrString("SENTRY_DSN", ""),
-			Enabled: len(GetEnvOrString("SENTRY_DSN", "")) > 0,
+			DSN:     l.String("SENTRY_DSN", ""),
+			Enabled: len(l.String("SENTRY_DSN", "")) > 0,
 		},
 		JobsEnqueuer: &JobsEnqueuerConfig{
 			Enabled:   false,
-			URL:       GetEnvOrString("REDIS_URL", ""),
-			Pool:      GetEnvOrInt("REDIS_POOL", 5),
-			Namespace: GetEnvOrString("REDIS_NAMESPACE", ""),
+			URL:       l.String("REDIS_URL", ""),
+			Pool:      l.Int("REDIS_POOL", 5),
+			Namespace: l.String("REDIS_NAMESPACE", ""),
+		},
+		Shutdown: &ShutdownConfig{
+			DrainDelay: l.Int("SHUTDOWN_DRAIN_DELAY", ShutdownDefaultDrainDelay),
+			Timeout:    l.Int("SHUTDOWN_TIMEOUT", ShutdownDefaultTimeout),
+		},
+		Admin: &AdminConfig{
+			Enabled: len(l.String("ADMIN_TOKEN", "")) > 0,
+			Token:   l.String("ADMIN_TOKEN", ""),
+		},
+		Secrets: &SecretsConfig{
+			RotationInterval: l.Int("SECRETS_ROTATION_INTERVAL", 0),
+		},
+		Server: &ServerConfig{
+			Port: l.Int("PORT", 51051),
+		},
+		Log: &LogConfig{
+			Level: l.String("LOG_LEVEL", "info"),
+		},
+		Cable: &CableConfig{
+			RedisChannel: l.String("ANYCABLE_REDIS_CHANNEL", "__anycable__"),
+		},
+		Hydra: &HydraConfig{
+			AdminURL: l.String("HYDRA_ADMIN_URL", ""),
 		},
 	}
+
+	c.loadErr = l.Err()
+
+	return c
 }
 
+// InitOption is an option to `Init`.
+type InitOption func(*Service)
+
 // Init initializes the Foundation service.
-func Init(name string) *Service {
-	return &Service{
-		Name:   name,
-		Config: NewConfig(),
-		Logger: initLogger(name),
+func Init(name string, opts ...InitOption) *Service {
+	s := &Service{
+		Name:         name,
+		configLoader: NewServiceConfigLoader(name),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	s.Logger = initLogger(name, s.logHandler, &s.logLevel)
+
+	s.Config = NewConfigFrom(s.configLoader)
+
+	// An invalid level is reported by `Config.Validate`
+	if level, err := parseLogLevel(s.Config.Log.Level); err == nil {
+		s.logLevel.Set(level)
+	}
+
+	fhydra.SetAdminURL(s.Config.Hydra.AdminURL)
+	s.OnSecretRotation("HYDRA_ADMIN_URL", func(value string) error {
+		fhydra.SetAdminURL(value)
+		return nil
+	})
+	s.OnSecretRotation("ADMIN_TOKEN", s.rotateAdminToken)
+
+	if s.AppConfig != nil {
+		if err := BindConfig(s.configLoader, s.AppConfig); err != nil {
+			logFatal(s.Logger, err.Error())
+		}
+	}
+
+	return s
 }
 
 // StartComponentsOption is an option to `StartComponents`.
@@ -219,6 +431,13 @@ func WithKafkaConsumerTopics(topics ...string) StartComponentsOption {
 	}
 }
 
+// WithKafkaConsumerRetryTopics sets the suffixes of the Kafka consumer retry topics, e.g. `.retry.1m`.
+func WithKafkaConsumerRetryTopics(suffixes ...string) StartComponentsOption {
+	return func(s *Service) {
+		s.Config.Kafka.Consumer.RetryTopicSuffixes = suffixes
+	}
+}
+
 // WithOutbox sets the outbox enabled flag.
 func WithOutbox() StartComponentsOption {
 	return func(s *Service) {
@@ -252,21 +471,25 @@ func (s *Service) addSystemComponents() error {
 
 	// PostgreSQL
 	if s.Config.Database.Enabled {
-		s.Components = append(s.Components, fpg.NewComponent(
+		pg := fpg.NewComponent(
 			fpg.WithDatabaseURL(s.Config.Database.URL),
 			fpg.WithLogger(s.Logger),
 			fpg.WithPoolSize(s.Config.Database.Pool),
-		))
+		)
+		s.OnSecretRotation("DATABASE_URL", pg.Reconnect)
+
+		s.Components = append(s.Components, pg)
 	}
 
 	// Kafka consumer
 	if s.Config.Kafka.Consumer.Enabled {
-		consumerComponents := make([]fkafka.ConsumerComponentOption, 5, 6)
+		consumerComponents := make([]fkafka.ConsumerComponentOption, 6, 7)
 		consumerComponents[0] = fkafka.WithConsumerAppName(s.Name)
 		consumerComponents[1] = fkafka.WithConsumerBrokers(s.Config.Kafka.Brokers)
 		consumerComponents[2] = fkafka.WithConsumerLogger(s.Logger)
 		consumerComponents[3] = fkafka.WithConsumerTLSDir(s.Config.Kafka.TLSDir)
 		consumerComponents[4] = fkafka.WithConsumerTopics(s.Config.Kafka.Consumer.Topics)
+		consumerComponents[5] = fkafka.WithConsumerRetryTopics(s.Config.Kafka.Consumer.RetryTopicSuffixes)
 
 		if s.Config.Kafka.SASL.Username != "" && s.Config.Kafka.SASL.Password != "" {
 			saslComponent, err := fkafka.WithSASLMechanism(s.Config.Kafka.SASL.Protocol, s.Config.Kafka.SASL.Username, s.Config.Kafka.SASL.Password)
@@ -301,11 +524,20 @@ func (s *Service) addSystemComponents() error {
 
 	// Metrics server
 	if s.Config.Metrics.Enabled {
-		s.Components = append(s.Components, NewMetricsServerComponent(
+		metricsOpts := []MetricsServerComponentOption{
 			WithMetricsServerHealthHandler(s.healthHandler),
+			WithMetricsServerLivenessHandler(s.livenessHandler),
+			WithMetricsServerReadinessHandler(s.readinessHandler),
+			WithMetricsServerStartupHandler(s.startupHandler),
 			WithMetricsServerLogger(s.Logger),
 			WithMetricsServerPort(s.Config.Metrics.Port),
-		))
+		}
+
+		if s.Config.Admin.Enabled {
+			metricsOpts = append(metricsOpts, WithMetricsServerAdminHandler(s.adminHandler()))
+		}
+
+		s.Components = append(s.Components, NewMetricsServerComponent(metricsOpts...))
 	}
 
 	// Redis
@@ -329,6 +561,11 @@ func (s *Service) addSystemComponents() error {
 		))
 	}
 
+	s.systemComponentNames = make([]string, 0, len(s.Components))
+	for _, component := range s.Components {
+		s.systemComponentNames = append(s.systemComponentNames, component.Name())
+	}
+
 	// Add user-defined components back
 	s.Components = append(s.Components, existedComponents...)
 
@@ -337,83 +574,224 @@ func (s *Service) addSystemComponents() error {
 
 // StartComponents starts the default Foundation service components.
 func (s *Service) StartComponents(opts ...StartComponentsOption) error {
+	if s.parent != nil {
+		return s.parent.StartComponents(opts...)
+	}
+
 	// Apply options
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	if err := s.Config.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.autoMigrate(); err != nil {
+		return err
+	}
+
 	if err := s.addSystemComponents(); err != nil {
 		return err
 	}
 
-	s.Logger.Info("Starting components:")
+	graph, err := newComponentGraph(s.Components, s.componentDependencies)
+	if err != nil {
+		return fmt.Errorf("failed to resolve components dependencies: %w", err)
+	}
+
+	s.componentGraph = graph
 
+	s.componentsByName = make(map[string]Component, len(s.Components))
 	for _, component := range s.Components {
-		s.Logger.Infof(" - %s", component.Name())
+		s.componentsByName[component.Name()] = component
+	}
 
-		if err := component.Start(); err != nil {
-			return fmt.Errorf("%s: %w", component.Name(), err)
-		}
+	s.Logger.Info("Starting components:")
+
+	if err = graph.start(func(component Component) error {
+		s.Logger.Info(" - " + component.Name())
+
+		return s.startComponent(component)
+	}); err != nil {
+		return err
 	}
 
+	s.componentsStarted.Store(true)
+
+	s.startSecretRotation()
+
 	return nil
 }
 
 // StopComponents stops the default Foundation service components.
 func (s *Service) StopComponents() {
+	if s.parent != nil {
+		s.parent.StopComponents()
+		return
+	}
+
+	if s.stopSecretRotation != nil {
+		s.stopSecretRotation()
+	}
+
+	if s.componentGraph == nil {
+		return
+	}
+
 	s.Logger.Info("Stopping components:")
 
-	// Stop components in reverse order, so that dependencies are stopped first
-	for i := len(s.Components) - 1; i >= 0; i-- {
-		s.Logger.Infof(" - %s", s.Components[i].Name())
+	// Stop components in reverse dependency order, so that dependents are stopped first
+	s.componentGraph.stop(func(component Component) {
+		s.Logger.Info(" - " + component.Name())
 
-		if err := s.Components[i].Stop(); err != nil {
-			err = fmt.Errorf("failed to stop component `%s`: %w", s.Components[i].Name(), err)
+		if err := component.Stop(); err != nil {
+			err = fmt.Errorf("failed to stop component `%s`: %w", component.Name(), err)
 			sentry.CaptureException(err)
-			s.Logger.Error(err)
+			s.Logger.Error(err.Error())
+		}
+	})
+}
+
+// componentDependencies returns the names of the components the given component depends on.
+func (s *Service) componentDependencies(component Component) []string {
+	if c, ok := component.(ComponentWithDependencies); ok {
+		return c.Dependencies()
+	}
+
+	// System components don't depend on each other
+	for _, name := range s.systemComponentNames {
+		if name == component.Name() {
+			return nil
 		}
 	}
+
+	// Application components are started after the system ones by default
+	return s.systemComponentNames
 }
 
+// StartOptions describes a running mode of the Foundation service.
 type StartOptions struct {
 	ModeName               string
 	StartComponentsOptions []StartComponentsOption
 	ServiceFunc            func(ctx context.Context) error
+
+	// service is the service the mode runs on, if it was built with the mode's `Mode` method.
+	service *Service
 }
 
-// Start runs the Foundation service.
+// Start runs the Foundation service in a single mode.
 func (s *Service) Start(opts *StartOptions) {
-	s.ModeName = opts.ModeName
+	s.StartModes(opts)
+}
+
+// StartModes runs the Foundation service in several modes within one process. All the modes share
+// the components, the metrics server and the shutdown sequence.
+//
+// N.B.: Only one of the modes may listen on `PORT` (e.g. `grpc` and `gateway` can't be combined),
+// and only one of them may consume Kafka events, as the Kafka consumer is shared.
+func (s *Service) StartModes(modes ...*StartOptions) {
+	root := s.root()
+
+	modeNames := make([]string, 0, len(modes))
+	var componentsOptions []StartComponentsOption
+
+	for _, mode := range modes {
+		modeNames = append(modeNames, mode.ModeName)
+		componentsOptions = append(componentsOptions, mode.StartComponentsOptions...)
+	}
+
+	root.ModeName = strings.Join(modeNames, "+")
 
 	// Set running mode to logger
-	s.Logger = s.Logger.WithField("mode", s.ModeName)
+	root.Logger = root.Logger.With("mode", root.ModeName)
+
+	// Each mode logs with its own name
+	for _, mode := range modes {
+		if mode.service != nil && mode.service != root {
+			mode.service.ModeName = mode.ModeName
+			mode.service.Logger = root.Logger.With("mode", mode.ModeName)
+		}
+	}
 
 	// Log application startup
-	s.logStartup()
+	root.logStartup(modeNames)
 
 	// Start common components
-	if err := s.StartComponents(opts.StartComponentsOptions...); err != nil {
+	if err := root.StartComponents(componentsOptions...); err != nil {
 		err = fmt.Errorf("failed to start components: %w", err)
 		sentry.CaptureException(err)
-		s.Logger.Fatalf("Failed to start components: %v", err)
+		logFatal(root.Logger, fmt.Sprintf("Failed to start components: %v", err))
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	s.cancelFunc = stop
+	// Shutdown is triggered either by a signal or by the service itself
+	shutdownCtx, triggerShutdown := context.WithCancel(signalCtx)
+	defer triggerShutdown()
 
-	// Run the actual service code
-	if err := opts.ServiceFunc(ctx); err != nil {
-		err = fmt.Errorf("failed to start service: %w", err)
+	root.cancelFunc = triggerShutdown
+
+	if err := root.runStartHooks(shutdownCtx); err != nil {
+		err = fmt.Errorf("failed to run start hooks: %w", err)
 		sentry.CaptureException(err)
-		s.Logger.Fatalf("Failed to start service: %v", err)
+		logFatal(root.Logger, fmt.Sprintf("Failed to run start hooks: %v", err))
+	}
+
+	// Run the actual service code. Its context is cancelled only after the drain delay,
+	// so that servers keep serving while the service is being taken out of rotation.
+	serviceCtx, stopService := context.WithCancel(context.Background())
+	defer stopService()
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		serviceErrs []error
+	)
+
+	failed := make(chan struct{}, len(modes))
+	serviceErr := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return errors.Join(serviceErrs...)
 	}
 
-	<-ctx.Done()
-	s.Logger.Println("Shutting down service...")
+	for _, mode := range modes {
+		wg.Add(1)
+
+		go func(mode *StartOptions) {
+			defer wg.Done()
+
+			if err := mode.ServiceFunc(fctx.WithMode(serviceCtx, mode.ModeName)); err != nil {
+				mu.Lock()
+				serviceErrs = append(serviceErrs, fmt.Errorf("%s: %w", mode.ModeName, err))
+				mu.Unlock()
+
+				failed <- struct{}{}
+			}
+		}(mode)
+	}
+
+	serviceDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(serviceDone)
+	}()
+
+	select {
+	case <-shutdownCtx.Done():
+	case <-failed:
+		err := fmt.Errorf("failed to start service: %w", serviceErr())
+		sentry.CaptureException(err)
+		logFatal(root.Logger, fmt.Sprintf("Failed to start service: %v", err))
+	}
 
-	s.StopComponents()
+	root.shutdown(stopService, func() error {
+		<-serviceDone
+		return serviceErr()
+	})
 
-	s.Logger.Println("Service gracefully stopped")
+	root.Logger.Info("Service gracefully stopped")
 }
@@ -29,9 +29,16 @@ type Gateway struct {
 }
 
 // InitGateway initializes a new Foundation service in Gateway mode.
-func InitGateway(name string) *Gateway {
+func InitGateway(name string, opts ...InitOption) *Gateway {
 	return &Gateway{
-		Service: Init(name),
+		Service: Init(name, opts...),
+	}
+}
+
+// NewGateway returns a gateway mode running on the given service, see `Service.StartModes`.
+func NewGateway(s *Service) *Gateway {
+	return &Gateway{
+		Service: s.newModeService(),
 	}
 }
 
@@ -67,18 +74,24 @@ func NewGatewayOptions() *GatewayOptions {
 
 // Start runs the Foundation gateway.
 func (s *Gateway) Start(opts *GatewayOptions) {
+	s.Service.Start(s.Mode(opts))
+}
+
+// Mode returns the gateway mode to run with `Service.StartModes`.
+func (s *Gateway) Mode(opts *GatewayOptions) *StartOptions {
 	s.Options = opts
 
-	s.Service.Start(&StartOptions{
+	return &StartOptions{
 		ModeName:               "gateway",
 		StartComponentsOptions: s.Options.StartComponentsOptions,
 		ServiceFunc:            s.ServiceFunc,
-	})
+		service:                s.Service,
+	}
 }
 
 func (s *Gateway) ServiceFunc(ctx context.Context) error {
 	gwruntime.DefaultContextTimeout = s.Options.Timeout
-	s.Logger.Debugf("Downstream requests timeout: %s", s.Options.Timeout)
+	s.Logger.Debug(fmt.Sprintf("Downstream requests timeout: %s", s.Options.Timeout))
 
 	tracingShutdown := s.initTracing()
 	defer tracingShutdown()
@@ -100,26 +113,26 @@ func (s *Gateway) ServiceFunc(ctx context.Context) error {
 		return fmt.Errorf("failed to register services: %w", err)
 	}
 
-	port := GetEnvOrInt("PORT", 51051)
+	port := s.Config.Server.Port
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: s.applyMiddleware(mux, s.Options),
 	}
 
-	s.Logger.Infof("Listening on http://0.0.0.0:%d", port)
+	s.Logger.Info(fmt.Sprintf("Listening on http://0.0.0.0:%d", port))
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			err = fmt.Errorf("failed to start server: %w", err)
 			sentry.CaptureException(err)
-			s.Logger.Fatal(err)
+			logFatal(s.Logger, err.Error())
 		}
 	}()
 
 	<-ctx.Done()
 
 	// Gracefully stop the HTTP server
-	if err := server.Shutdown(context.Background()); err != nil {
+	if err := server.Shutdown(s.ShutdownContext()); err != nil {
 		err = fmt.Errorf("failed to gracefully shutdown HTTP server: %w", err)
 		return err
 	}
@@ -161,6 +174,6 @@ func (s *Service) logMiddlewareChain(middleware []func(http.Handler) http.Handle
 	s.Logger.Info("Using middleware:")
 
 	for _, m := range middleware {
-		s.Logger.Infof(" - %s", runtime.FuncForPC(reflect.ValueOf(m).Pointer()).Name())
+		s.Logger.Info(fmt.Sprintf(" - %s", runtime.FuncForPC(reflect.ValueOf(m).Pointer()).Name()))
 	}
 }
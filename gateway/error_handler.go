@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/getsentry/sentry-go"
@@ -17,7 +18,7 @@ func ErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.
 
 	switch code := status.Code(err); code {
 	case codes.Internal:
-		log.Errorf("internal error: %v", err)
+		log.Error(fmt.Sprintf("internal error: %v", err))
 		sentry.CaptureException(err)
 
 		err = &runtime.HTTPStatusError{
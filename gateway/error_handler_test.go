@@ -3,13 +3,13 @@ package gateway
 import (
 	"context"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -28,7 +28,7 @@ func TestErrorHandler(t *testing.T) {
 
 	internalError := status.Error(codes.Internal, "private error")
 	ctx := context.Background()
-	req = req.WithContext(fctx.WithLogger(ctx, logrus.NewEntry(logrus.New())))
+	req = req.WithContext(fctx.WithLogger(ctx, slog.New(slog.NewTextHandler(io.Discard, nil))))
 
 	ErrorHandler(ctx, mux, marshaler, w, req, internalError)
 
@@ -1,11 +1,10 @@
 package gateway
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	fctx "github.com/foundation-go/foundation/context"
 	fhttp "github.com/foundation-go/foundation/http"
 
@@ -30,7 +29,7 @@ func (lrw *LoggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
-func WithRequestLogger(l *log.Entry) func(http.Handler) http.Handler {
+func WithRequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 			// Record the start time of the request
@@ -44,11 +43,7 @@ func WithRequestLogger(l *log.Entry) func(http.Handler) http.Handler {
 			writer.Header().Set(fhttp.HeaderXCorrelationID, requestID)
 
 			// Add the logger to the request context
-			l = l.WithFields(log.Fields{
-				"method":     request.Method,
-				"path":       request.URL.Path,
-				"request_id": requestID,
-			})
+			l := logger.With("method", request.Method, "path", request.URL.Path, "request_id", requestID)
 			ctx := fctx.WithLogger(request.Context(), l)
 			request = request.WithContext(ctx)
 
@@ -56,12 +51,12 @@ func WithRequestLogger(l *log.Entry) func(http.Handler) http.Handler {
 			lrw := NewLoggingResponseWriter(writer)
 
 			// Serve the request with the wrapped response writer
-			l.Infoln("Request started")
+			l.Info("Request started")
 			handler.ServeHTTP(lrw, request)
 
 			// Calculate the duration of the request
 			duration := time.Since(started)
-			l.WithField("duration_ms", duration.Milliseconds()).WithField("status", lrw.statusCode).Infoln("Request finished")
+			l.Info("Request finished", "duration_ms", duration.Milliseconds(), "status", lrw.statusCode)
 		})
 	}
 }
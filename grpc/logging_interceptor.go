@@ -2,8 +2,8 @@ package grpc
 
 import (
 	"context"
+	"log/slog"
 
-	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
 	fctx "github.com/foundation-go/foundation/context"
@@ -11,16 +11,13 @@ import (
 
 // LoggingUnaryInterceptor returns a gRPC unary interceptor that logs all incoming gRPC calls.
 // It logs the method details, request, response, and any potential errors.
-func LoggingUnaryInterceptor(log *logrus.Entry) func(context.Context, interface{}, *grpc.UnaryServerInfo, grpc.UnaryHandler) (interface{}, error) {
+func LoggingUnaryInterceptor(logger *slog.Logger) func(context.Context, interface{}, *grpc.UnaryServerInfo, grpc.UnaryHandler) (interface{}, error) {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		// Enhance the log with request-related fields.
-		log = log.WithFields(logrus.Fields{
-			"method":         info.FullMethod,
-			"correlation_id": fctx.GetCorrelationID(ctx),
-		})
+		log := logger.With("method", info.FullMethod, "correlation_id", fctx.GetCorrelationID(ctx))
 
 		log.Info("Call started")
-		log.WithField("request", req).Debug("Request")
+		log.Debug("Request", "request", req)
 
 		// Add logger to context
 		ctx = fctx.WithLogger(ctx, log)
@@ -30,11 +27,11 @@ func LoggingUnaryInterceptor(log *logrus.Entry) func(context.Context, interface{
 
 		// Process handling error if any
 		if err != nil {
-			log.WithError(err).Error("Call failed")
+			log.Error("Call failed", "error", err)
 			return nil, err
 		}
 
-		log.WithField("response", resp).Debug("Response")
+		log.Debug("Response", "response", resp)
 		log.Info("Call finished")
 
 		return resp, nil
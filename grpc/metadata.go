@@ -17,6 +17,9 @@ import (
 // we are writing gRPC or Event Bus handlers.
 func MetadataUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	ctx = fctx.WithCorrelationID(ctx, getCorrelationID(ctx))
+	// The correlation ID is generated by the gateway for each request, so it identifies the request as the cause
+	// of the events published while handling it.
+	ctx = fctx.WithCausationID(ctx, getCorrelationID(ctx))
 	ctx = fctx.WithClientID(ctx, getClientID(ctx))
 	ctx = fctx.WithScopes(ctx, getScopes(ctx))
 	ctx = fctx.WithUserID(ctx, getUserID(ctx))
@@ -19,9 +19,16 @@ type GRPCServer struct {
 }
 
 // InitGRPCServer initializes a new Foundation service in gRPC Server mode.
-func InitGRPCServer(name string) *GRPCServer {
+func InitGRPCServer(name string, opts ...InitOption) *GRPCServer {
 	return &GRPCServer{
-		Service: Init(name),
+		Service: Init(name, opts...),
+	}
+}
+
+// NewGRPCServer returns a gRPC server mode running on the given service, see `Service.StartModes`.
+func NewGRPCServer(s *Service) *GRPCServer {
+	return &GRPCServer{
+		Service: s.newModeService(),
 	}
 }
 
@@ -43,13 +50,19 @@ func NewGRPCServerOptions() *GRPCServerOptions {
 
 // Start initializes the Foundation service in gRPC server mode.
 func (s *GRPCServer) Start(opts *GRPCServerOptions) {
+	s.Service.Start(s.Mode(opts))
+}
+
+// Mode returns the gRPC server mode to run with `Service.StartModes`.
+func (s *GRPCServer) Mode(opts *GRPCServerOptions) *StartOptions {
 	s.Options = opts
 
-	s.Service.Start(&StartOptions{
+	return &StartOptions{
 		ModeName:               "grpc",
 		StartComponentsOptions: s.Options.StartComponentsOptions,
 		ServiceFunc:            s.ServiceFunc,
-	})
+		service:                s.Service,
+	}
 }
 
 func (s *GRPCServer) ServiceFunc(ctx context.Context) error {
@@ -72,7 +85,7 @@ func (s *GRPCServer) ServiceFunc(ctx context.Context) error {
 
 	// TLS
 	if s.Config.GRPC.TLSDir != "" {
-		s.Logger.Debugf("gRPC mTLS is enabled, loading certificates from %s", s.Config.GRPC.TLSDir)
+		s.Logger.Debug(fmt.Sprintf("gRPC mTLS is enabled, loading certificates from %s", s.Config.GRPC.TLSDir))
 
 		tlsConfig, err := fg.NewTLSConfig(s.Config.GRPC.TLSDir)
 		if err != nil {
@@ -94,28 +107,28 @@ func (s *GRPCServer) ServiceFunc(ctx context.Context) error {
 		if err := server.Serve(listener); err != nil {
 			err = fmt.Errorf("failed to start server: %w", err)
 			sentry.CaptureException(err)
-			s.Logger.Fatal(err)
+			logFatal(s.Logger, err.Error())
 		}
 	}()
 
 	<-ctx.Done()
 
 	// Gracefully stop the server
-	server.GracefulStop()
+	s.gracefulStopGRPC(server)
 
 	return nil
 }
 
 func (s *Service) acquireListener() net.Listener {
-	port := GetEnvOrInt("PORT", 51051)
+	port := s.Config.Server.Port
 	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
 	if err != nil {
 		err = fmt.Errorf("failed to listen port %d: %w", port, err)
 		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+		logFatal(s.Logger, err.Error())
 	}
 
-	s.Logger.Infof("Listening on http://0.0.0.0:%d", port)
+	s.Logger.Info(fmt.Sprintf("Listening on http://0.0.0.0:%d", port))
 
 	return listener
 }
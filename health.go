@@ -1,27 +1,185 @@
 package foundation
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 )
 
-func (s *Service) healthHandler(w http.ResponseWriter, r *http.Request) {
-	for _, component := range s.Components {
-		started := time.Now()
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// ComponentWithCriticality describes a component that can mark itself as non-critical.
+//
+// A failing non-critical component doesn't make the service unready, it only degrades
+// the health report. Components that don't implement this interface are critical.
+type ComponentWithCriticality interface {
+	Component
+
+	// Critical returns whether the service can't serve traffic without the component
+	Critical() bool
+}
+
+// HealthReport is the JSON body returned by the health endpoints.
+type HealthReport struct {
+	Status     string                  `json:"status"`
+	Components []ComponentHealthReport `json:"components,omitempty"`
+}
+
+// ComponentHealthReport is the health of a single component.
+type ComponentHealthReport struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+func isCriticalComponent(component Component) bool {
+	if c, ok := component.(ComponentWithCriticality); ok {
+		return c.Critical()
+	}
+
+	return true
+}
+
+// checkHealth runs the health checks of all components concurrently, each one limited
+// by the configured timeout.
+func (s *Service) checkHealth() *HealthReport {
+	timeout := time.Duration(s.healthCheckTimeout()) * time.Second
+
+	report := &HealthReport{
+		Status:     HealthStatusOK,
+		Components: make([]ComponentHealthReport, len(s.Components)),
+	}
+
+	var wg sync.WaitGroup
+	for i, component := range s.Components {
+		wg.Add(1)
+
+		go func(i int, component Component) {
+			defer wg.Done()
+
+			started := time.Now()
+			err := checkComponentHealth(component, timeout)
+
+			report.Components[i] = ComponentHealthReport{
+				Name:      component.Name(),
+				Status:    HealthStatusOK,
+				Critical:  isCriticalComponent(component),
+				LatencyMs: time.Since(started).Milliseconds(),
+			}
+
+			if err != nil {
+				report.Components[i].Status = HealthStatusUnavailable
+				report.Components[i].Error = err.Error()
+
+				err = fmt.Errorf("health check failed for `%s`: %w", component.Name(), err)
+				sentry.CaptureException(err)
+				s.Logger.Error(err.Error())
+				return
+			}
+
+			s.Logger.Debug(fmt.Sprintf("Health check for `%s` took %dms", component.Name(), report.Components[i].LatencyMs))
+		}(i, component)
+	}
+
+	wg.Wait()
+
+	for _, c := range report.Components {
+		if c.Status == HealthStatusOK {
+			continue
+		}
 
-		if err := component.Health(); err != nil {
-			err = fmt.Errorf("health check failed for `%s`: %w", component.Name(), err)
-			sentry.CaptureException(err)
-			s.Logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+		if c.Critical {
+			report.Status = HealthStatusUnavailable
+			break
 		}
 
-		s.Logger.Debugf("Health check for `%s` took %dms", component.Name(), time.Since(started).Milliseconds())
+		report.Status = HealthStatusDegraded
+	}
+
+	return report
+}
+
+func (s *Service) healthCheckTimeout() int {
+	if s.Config.Metrics == nil || s.Config.Metrics.HealthCheckTimeout <= 0 {
+		return MetricsDefaultHealthCheckTimeout
+	}
+
+	return s.Config.Metrics.HealthCheckTimeout
+}
+
+// checkComponentHealth runs the component health check, giving up after the timeout.
+func checkComponentHealth(component Component, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("health check panicked: %v", r)
+			}
+		}()
+
+		result <- component.Health()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return errors.New("health check timed out")
+	}
+}
+
+func writeHealthReport(w http.ResponseWriter, report *HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if report.Status == HealthStatusUnavailable {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// healthHandler reports the health of all the components. Only critical components make
+// the service unhealthy.
+func (s *Service) healthHandler(w http.ResponseWriter, _ *http.Request) {
+	writeHealthReport(w, s.checkHealth())
+}
+
+// readinessHandler reports whether the service is ready to serve traffic. The service is not
+// ready until all the components have been started, and once it has started shutting down.
+func (s *Service) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.componentsStarted.Load() || s.shuttingDown.Load() {
+		writeHealthReport(w, &HealthReport{Status: HealthStatusUnavailable})
+		return
+	}
+
+	s.healthHandler(w, r)
+}
+
+// livenessHandler reports whether the process is alive. It doesn't check the components,
+// so an outage of a dependency doesn't cause the service to be restarted.
+func (s *Service) livenessHandler(w http.ResponseWriter, _ *http.Request) {
+	writeHealthReport(w, &HealthReport{Status: HealthStatusOK})
+}
+
+// startupHandler reports whether all the components have been started.
+func (s *Service) startupHandler(w http.ResponseWriter, _ *http.Request) {
+	if !s.componentsStarted.Load() {
+		writeHealthReport(w, &HealthReport{Status: HealthStatusUnavailable})
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	writeHealthReport(w, &HealthReport{Status: HealthStatusOK})
 }
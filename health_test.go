@@ -1,6 +1,9 @@
 package foundation
 
 import (
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,7 +15,7 @@ import (
 func TestHealthHandler(t *testing.T) {
 	app := &Service{
 		Config: &Config{},
-		Logger: initLogger("test"),
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
 	}
 
 	// Actual test function
@@ -47,3 +50,66 @@ func TestHealthHandler(t *testing.T) {
 	app.Components = []Component{&fkafka.ProducerComponent{}}
 	assertExample(app, http.StatusInternalServerError)
 }
+
+func TestHealthReport(t *testing.T) {
+	app := &Service{
+		Config: &Config{},
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
+		Components: []Component{
+			&testComponent{name: "postgresql"},
+			&nonCriticalComponent{testComponent{name: "sentry", health: errors.New("unreachable")}},
+		},
+	}
+
+	serve := func(handler http.HandlerFunc) (*httptest.ResponseRecorder, *HealthReport) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		report := &HealthReport{}
+		if err := json.NewDecoder(w.Body).Decode(report); err != nil {
+			t.Fatalf("could not decode health report: %v", err)
+		}
+
+		return w, report
+	}
+
+	// Not ready until components are started
+	w, report := serve(app.readinessHandler)
+	if w.Code != http.StatusInternalServerError || report.Status != HealthStatusUnavailable {
+		t.Errorf("Expected service to be unready, but got %d (%s)", w.Code, report.Status)
+	}
+
+	w, _ = serve(app.livenessHandler)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected service to be alive, but got %d", w.Code)
+	}
+
+	app.componentsStarted.Store(true)
+
+	// Failing non-critical component only degrades the service
+	w, report = serve(app.readinessHandler)
+	if w.Code != http.StatusOK || report.Status != HealthStatusDegraded {
+		t.Errorf("Expected service to be degraded, but got %d (%s)", w.Code, report.Status)
+	}
+
+	if len(report.Components) != 2 || report.Components[1].Error != "unreachable" || report.Components[1].Critical {
+		t.Errorf("Unexpected components report: %+v", report.Components)
+	}
+
+	// Failing critical component makes the service unready
+	app.Components[0].(*testComponent).health = errors.New("connection refused")
+
+	w, report = serve(app.readinessHandler)
+	if w.Code != http.StatusInternalServerError || report.Status != HealthStatusUnavailable {
+		t.Errorf("Expected service to be unready, but got %d (%s)", w.Code, report.Status)
+	}
+}
+
+type nonCriticalComponent struct {
+	testComponent
+}
+
+func (c *nonCriticalComponent) Critical() bool { return false }
@@ -17,9 +17,17 @@ type HTTPServer struct {
 }
 
 // InitHTTPServer initializes a new Foundation service in HTTP Server mode.
-func InitHTTPServer(name string) *HTTPServer {
+func InitHTTPServer(name string, opts ...InitOption) *HTTPServer {
 	return &HTTPServer{
-		Init(name),
+		Init(name, opts...),
+		NewHTTPServerOptions(),
+	}
+}
+
+// NewHTTPServer returns a HTTP server mode running on the given service, see `Service.StartModes`.
+func NewHTTPServer(s *Service) *HTTPServer {
+	return &HTTPServer{
+		s.newModeService(),
 		NewHTTPServerOptions(),
 	}
 }
@@ -39,39 +47,43 @@ func NewHTTPServerOptions() *HTTPServerOptions {
 
 // Start runs the Foundation service in HTTP Server mode.
 func (s *HTTPServer) Start(opts *HTTPServerOptions) {
+	s.Service.Start(s.Mode(opts))
+}
+
+// Mode returns the HTTP server mode to run with `Service.StartModes`.
+func (s *HTTPServer) Mode(opts *HTTPServerOptions) *StartOptions {
 	s.Options = opts
 
-	s.Service.Start(&StartOptions{
+	return &StartOptions{
 		ModeName:               "http_server",
 		StartComponentsOptions: s.Options.StartComponentsOptions,
 		ServiceFunc:            s.ServiceFunc,
-	})
+		service:                s.Service,
+	}
 }
 
 func (s *HTTPServer) ServiceFunc(ctx context.Context) error {
-	port := GetEnvOrInt("PORT", 51051)
+	port := s.Config.Server.Port
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: s.Options.Handler,
 	}
 
-	s.Logger.Infof("Listening on http://0.0.0.0:%d", port)
+	s.Logger.Info(fmt.Sprintf("Listening on http://0.0.0.0:%d", port))
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			err = fmt.Errorf("failed to start HTTP server: %w", err)
 			sentry.CaptureException(err)
-			s.Logger.Fatal(err)
+			logFatal(s.Logger, err.Error())
 		}
 	}()
 
 	<-ctx.Done()
 
 	// Gracefully stop the HTTP server
-	if err := server.Shutdown(context.Background()); err != nil {
-		err = fmt.Errorf("failed to gracefully shutdown HTTP server: %w", err)
-		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+	if err := server.Shutdown(s.ShutdownContext()); err != nil {
+		return fmt.Errorf("failed to gracefully shutdown HTTP server: %w", err)
 	}
 
 	return nil
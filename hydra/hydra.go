@@ -3,13 +3,19 @@ package hydra
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 
 	hydra "github.com/ory/hydra-client-go/v2"
 )
 
 func IntrospectedOAuth2Token(ctx context.Context, token string) (*hydra.IntrospectedOAuth2Token, error) {
-	hydraAdminURL := os.Getenv("HYDRA_ADMIN_URL")
+	hydraAdminURL, err := adminURL()
+	if err != nil {
+		return nil, err
+	}
 	if hydraAdminURL == "" {
 		return nil, errors.New("HYDRA_ADMIN_URL is not set")
 	}
@@ -32,3 +38,36 @@ func IntrospectedOAuth2Token(ctx context.Context, token string) (*hydra.Introspe
 
 	return resp, nil
 }
+
+// configuredAdminURL is the Hydra Admin API URL resolved by the service configuration, see `SetAdminURL`.
+var configuredAdminURL atomic.Value
+
+// SetAdminURL sets the Hydra Admin API URL, e.g. resolved from the configuration files or the secret
+// providers. It may be called again when the URL is rotated.
+func SetAdminURL(url string) {
+	configuredAdminURL.Store(url)
+}
+
+// adminURL returns the Hydra Admin API URL set with `SetAdminURL`. Otherwise, it is read from
+// `HYDRA_ADMIN_URL`, or from the file referenced by `HYDRA_ADMIN_URL_FILE`.
+func adminURL() (string, error) {
+	if value, _ := configuredAdminURL.Load().(string); value != "" {
+		return value, nil
+	}
+
+	if value := os.Getenv("HYDRA_ADMIN_URL"); value != "" {
+		return value, nil
+	}
+
+	path := os.Getenv("HYDRA_ADMIN_URL_FILE")
+	if path == "" {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HYDRA_ADMIN_URL_FILE: %w", err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -22,9 +22,10 @@ var DBMigrate = &cobra.Command{
 	Use:     "db:migrate",
 	Aliases: []string{"dbm"},
 	Short:   "Run database migrations",
+	Long:    "Run the framework migrations (e.g. the outbox tables), tracked in the `" + f.FrameworkMigrationsTable + "` table, then the service migrations",
 	Run: func(cmd *cobra.Command, _ []string) {
 		var dir string
-		databaseURL := f.GetEnvOrString("DATABASE_URL", "")
+		databaseURL := f.NewConfigLoader().String("DATABASE_URL", "")
 
 		if f.IsProductionEnv() {
 			dir = cmd.Flag("dir").Value.String()
@@ -49,6 +50,13 @@ var DBMigrate = &cobra.Command{
 			log.Fatal("`DATABASE_URL` environment variable is not set")
 		}
 
+		skipFramework, _ := cmd.Flags().GetBool("skip-framework")
+		if !skipFramework {
+			if err = f.MigrateFramework(databaseURL, f.NewConfig().Outbox); err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		m, err := migrate.New(fmt.Sprintf("file://%s", dir), databaseURL)
 		if err != nil {
 			log.Fatal(err)
@@ -65,4 +73,6 @@ func init() {
 	if err := DBMigrate.MarkFlagDirname("dir"); err != nil {
 		log.Fatal(err)
 	}
+
+	DBMigrate.Flags().Bool("skip-framework", false, "Skip the framework migrations")
 }
@@ -21,7 +21,7 @@ var DBRollback = &cobra.Command{
 	Long:    "Rollback database migrations by a given number of steps, e.g.: `foundation db:rollback --steps 2`",
 	Run: func(cmd *cobra.Command, _ []string) {
 		var dir string
-		databaseURL := f.GetEnvOrString("DATABASE_URL", "")
+		databaseURL := f.NewConfigLoader().String("DATABASE_URL", "")
 
 		if f.IsProductionEnv() {
 			dir = cmd.Flag("dir").Value.String()
@@ -0,0 +1,289 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/spf13/cobra"
+
+	f "github.com/foundation-go/foundation"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+// deadLetterMaxBytes is the maximum size of the batches read from the dead-letter topic.
+const deadLetterMaxBytes = 10 << 20
+
+var EventsDLQ = &cobra.Command{
+	Use:   "events:dlq",
+	Short: "List dead-lettered events",
+	Long: "List the events the events workers failed to handle, published to `EVENTS_WORKER_DLQ_TOPIC`, e.g.: " +
+		"`foundation events:dlq --service chats`. The events are identified by their `<partition>:<offset>` in the topic.",
+	Run: func(cmd *cobra.Command, _ []string) {
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil || limit <= 0 {
+			log.Fatal("You should set `--limit` flag to a positive integer")
+		}
+		service, _ := cmd.Flags().GetString("service")
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSERVICE\tTOPIC\tKEY\tEVENT\tHANDLER\tATTEMPTS\tFAILED AT\tERROR")
+
+		count := 0
+		readDeadLetters(func(msg kafka.Message) bool {
+			headers := deadLetterHeaders(msg)
+			if service != "" && headers[fkafka.HeaderDLQService] != service {
+				return true
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				deadLetterID(msg), headers[fkafka.HeaderDLQService], headers[fkafka.HeaderOriginalTopic], msg.Key,
+				headers[fkafka.HeaderProtoName], headers[fkafka.HeaderDLQHandler], headers[fkafka.HeaderDLQAttempts],
+				headers[fkafka.HeaderDLQFailedAt], headers[fkafka.HeaderDLQError])
+			count++
+
+			return count < limit
+		})
+
+		if count == 0 {
+			fmt.Println("No dead-lettered events")
+			return
+		}
+		_ = w.Flush()
+	},
+}
+
+var EventsDLQInspect = &cobra.Command{
+	Use:   "inspect <id>",
+	Short: "Inspect a dead-lettered event",
+	Long:  "Print the headers and the payload of a dead-lettered event, e.g.: `foundation events:dlq inspect 0:42`",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		msg := readDeadLetter(args[0])
+		headers := deadLetterHeaders(msg)
+
+		keys := make([]string, 0, len(headers))
+		for key := range headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "id\t%s\n", deadLetterID(msg))
+		fmt.Fprintf(w, "key\t%s\n", msg.Key)
+		fmt.Fprintf(w, "time\t%s\n", msg.Time)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\n", key, headers[key])
+		}
+		fmt.Fprintf(w, "payload (base64)\t%s\n", base64.StdEncoding.EncodeToString(msg.Value))
+		_ = w.Flush()
+	},
+}
+
+var EventsDLQRedrive = &cobra.Command{
+	Use:   "redrive [<id>...]",
+	Short: "Redrive dead-lettered events",
+	Long: "Publish dead-lettered events back to their source topic, to be handled again by the service that failed " +
+		"to handle them, e.g.: `foundation events:dlq redrive 0:42` or `foundation events:dlq redrive --all --service chats`. " +
+		"The events are kept in the dead-letter topic, so redriving them twice publishes them twice.",
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		service, _ := cmd.Flags().GetString("service")
+
+		if all == (len(args) > 0) {
+			log.Fatal("You should set either the event IDs or the `--all` flag")
+		}
+
+		var messages []kafka.Message
+		if all {
+			readDeadLetters(func(msg kafka.Message) bool {
+				if service == "" || deadLetterHeaders(msg)[fkafka.HeaderDLQService] == service {
+					messages = append(messages, msg)
+				}
+
+				return true
+			})
+		} else {
+			for _, id := range args {
+				messages = append(messages, readDeadLetter(id))
+			}
+		}
+
+		redriven := make([]kafka.Message, 0, len(messages))
+		for _, msg := range messages {
+			message, err := f.NewRedriveMessage(msg)
+			if err != nil {
+				log.Fatalf("Cannot redrive event %s: %v", deadLetterID(msg), err)
+			}
+			redriven = append(redriven, message)
+		}
+
+		if len(redriven) > 0 {
+			config := f.NewConfig()
+			_, transport := kafkaDialerAndTransport(config)
+
+			writer := &kafka.Writer{
+				Addr:      kafka.TCP(config.Kafka.Brokers...),
+				Balancer:  &kafka.Hash{},
+				Transport: transport,
+			}
+			defer writer.Close() // nolint: errcheck
+
+			if err := writer.WriteMessages(context.Background(), redriven...); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		fmt.Printf("%d dead-lettered events redriven\n", len(redriven))
+	},
+}
+
+func init() {
+	EventsDLQ.Flags().IntP("limit", "l", 100, "Maximum number of events to list")
+	EventsDLQ.PersistentFlags().StringP("service", "s", "", "Only the events the given service failed to handle")
+	EventsDLQRedrive.Flags().Bool("all", false, "Redrive all the dead-lettered events")
+
+	EventsDLQ.AddCommand(EventsDLQInspect, EventsDLQRedrive)
+}
+
+// kafkaDialerAndTransport returns the Kafka dialer and transport configured with `KAFKA_TLS_DIR` and `KAFKA_SASL_*`.
+func kafkaDialerAndTransport(config *f.Config) (*kafka.Dialer, *kafka.Transport) {
+	if len(config.Kafka.Brokers) == 0 {
+		log.Fatal("`KAFKA_BROKERS` environment variable is not set")
+	}
+
+	sasl := config.Kafka.SASL
+	dialer, transport, err := fkafka.NewDialerAndTransport(config.Kafka.TLSDir, sasl.Protocol, sasl.Username, sasl.Password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return dialer, transport
+}
+
+// dlqTopic returns the dead-letter topic configured with `EVENTS_WORKER_DLQ_TOPIC`.
+func dlqTopic(config *f.Config) string {
+	if config.EventsWorker.DLQTopic == "" {
+		log.Fatal("`EVENTS_WORKER_DLQ_TOPIC` environment variable is empty")
+	}
+
+	return config.EventsWorker.DLQTopic
+}
+
+// readDeadLetters calls fn with the messages of the dead-letter topic, partition by partition, until it returns false.
+func readDeadLetters(fn func(kafka.Message) bool) {
+	config := f.NewConfig()
+	topic := dlqTopic(config)
+	dialer, _ := kafkaDialerAndTransport(config)
+
+	partitions, err := dialer.LookupPartitions(context.Background(), "tcp", config.Kafka.Brokers[0], topic)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].ID < partitions[j].ID })
+
+	for _, partition := range partitions {
+		if !readDeadLettersPartition(dialer, config.Kafka.Brokers[0], topic, partition.ID, fn) {
+			return
+		}
+	}
+}
+
+// readDeadLettersPartition calls fn with the messages of the dead-letter topic partition. It returns false if fn did.
+func readDeadLettersPartition(dialer *kafka.Dialer, broker, topic string, partition int, fn func(kafka.Message) bool) bool {
+	conn, err := dialer.DialLeader(context.Background(), "tcp", broker, topic, partition)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close() // nolint: errcheck
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for offset := first; offset < last; {
+		if _, err = conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+			log.Fatal(err)
+		}
+
+		batch := conn.ReadBatch(1, deadLetterMaxBytes)
+		start := offset
+		for offset < last {
+			msg, err := batch.ReadMessage()
+			if err != nil {
+				break
+			}
+			offset = msg.Offset + 1
+
+			if !fn(msg) {
+				_ = batch.Close()
+				return false
+			}
+		}
+
+		if err = batch.Close(); err != nil {
+			log.Fatal(err)
+		}
+
+		// Nothing left to read, e.g. the last offsets are transaction markers
+		if offset == start {
+			break
+		}
+	}
+
+	return true
+}
+
+// readDeadLetter returns the message of the dead-letter topic with the given `<partition>:<offset>` ID.
+func readDeadLetter(id string) kafka.Message {
+	partitionArg, offsetArg, _ := strings.Cut(id, ":")
+	partition, err := strconv.Atoi(partitionArg)
+	if err != nil {
+		log.Fatalf("Invalid event ID `%s`, expected `<partition>:<offset>`", id)
+	}
+	offset, err := strconv.ParseInt(offsetArg, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid event ID `%s`, expected `<partition>:<offset>`", id)
+	}
+
+	config := f.NewConfig()
+	dialer, _ := kafkaDialerAndTransport(config)
+
+	conn, err := dialer.DialLeader(context.Background(), "tcp", config.Kafka.Brokers[0], dlqTopic(config), partition)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close() // nolint: errcheck
+
+	if _, err = conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		log.Fatalf("Dead-lettered event %s not found: %v", id, err)
+	}
+
+	msg, err := conn.ReadMessage(deadLetterMaxBytes)
+	if err != nil || msg.Offset != offset {
+		log.Fatalf("Dead-lettered event %s not found", id)
+	}
+
+	return msg
+}
+
+func deadLetterID(msg kafka.Message) string {
+	return fmt.Sprintf("%d:%d", msg.Partition, msg.Offset)
+}
+
+func deadLetterHeaders(msg kafka.Message) map[string]string {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	return headers
+}
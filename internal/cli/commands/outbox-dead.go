@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+
+	f "github.com/foundation-go/foundation"
+	"github.com/foundation-go/foundation/outboxrepo"
+)
+
+var OutboxDead = &cobra.Command{
+	Use:   "outbox:dead",
+	Short: "List dead outbox events",
+	Long:  "List the outbox events that failed to publish after all the retry attempts, e.g.: `foundation outbox:dead --limit 10`",
+	Run: func(cmd *cobra.Command, _ []string) {
+		limit, err := cmd.Flags().GetInt32("limit")
+		if err != nil || limit <= 0 {
+			log.Fatal("You should set `--limit` flag to a positive integer")
+		}
+
+		conn := connectOutboxDatabase()
+		defer conn.Close(context.Background()) // nolint: errcheck
+
+		events, err := outboxrepo.NewWithTables(conn, outboxTables()).ListOutboxDeadEvents(context.Background(), limit)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(events) == 0 {
+			fmt.Println("No dead outbox events")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTOPIC\tKEY\tATTEMPTS\tDEAD AT\tLAST ERROR")
+		for _, event := range events {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n",
+				event.ID, event.Topic, event.Key, event.Attempts, event.DeadAt.Time.Format(time.RFC3339), event.LastError)
+		}
+		_ = w.Flush()
+	},
+}
+
+var OutboxDeadInspect = &cobra.Command{
+	Use:   "inspect <id>",
+	Short: "Inspect a dead outbox event",
+	Long:  "Print the headers, the payload and the last error of a dead outbox event, e.g.: `foundation outbox:dead inspect 42`",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		id := outboxEventID(args[0])
+
+		conn := connectOutboxDatabase()
+		defer conn.Close(context.Background()) // nolint: errcheck
+
+		event, err := outboxrepo.NewWithTables(conn, outboxTables()).GetOutboxDeadEvent(context.Background(), id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Fatalf("Dead outbox event %d not found", id)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		headers := make(map[string]string)
+		if err = json.Unmarshal(event.Headers, &headers); err != nil {
+			log.Fatalf("Invalid headers of dead outbox event %d: %v", id, err)
+		}
+
+		keys := make([]string, 0, len(headers))
+		for key := range headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "id\t%d\n", event.ID)
+		fmt.Fprintf(w, "topic\t%s\n", event.Topic)
+		fmt.Fprintf(w, "key\t%s\n", event.Key)
+		fmt.Fprintf(w, "created at\t%s\n", event.CreatedAt.Time.Format(time.RFC3339))
+		fmt.Fprintf(w, "dead at\t%s\n", event.DeadAt.Time.Format(time.RFC3339))
+		fmt.Fprintf(w, "attempts\t%d\n", event.Attempts)
+		fmt.Fprintf(w, "last error\t%s\n", event.LastError)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\n", key, headers[key])
+		}
+		fmt.Fprintf(w, "payload (base64)\t%s\n", base64.StdEncoding.EncodeToString(event.Payload))
+		_ = w.Flush()
+	},
+}
+
+var OutboxDeadRequeue = &cobra.Command{
+	Use:   "requeue <id>",
+	Short: "Requeue a dead outbox event",
+	Long:  "Move a dead outbox event back to the outbox to be published again, e.g.: `foundation outbox:dead requeue 42`",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		runOutboxDeadEventCommand(args[0], "requeued", func(q *outboxrepo.Queries, id int64) (int64, error) {
+			rows, err := q.RequeueOutboxDeadEvent(context.Background(), id)
+			if err != nil || rows == 0 {
+				return rows, err
+			}
+
+			return rows, notifyOutboxCouriers(q)
+		})
+	},
+}
+
+var OutboxDeadDiscard = &cobra.Command{
+	Use:   "discard <id>",
+	Short: "Discard a dead outbox event",
+	Long:  "Delete a dead outbox event for good, e.g.: `foundation outbox:dead discard 42`",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		runOutboxDeadEventCommand(args[0], "discarded", func(q *outboxrepo.Queries, id int64) (int64, error) {
+			return q.DeleteOutboxDeadEvent(context.Background(), id)
+		})
+	},
+}
+
+func init() {
+	OutboxDead.Flags().Int32P("limit", "l", 100, "Maximum number of events to list")
+
+	OutboxDead.AddCommand(OutboxDeadInspect, OutboxDeadRequeue, OutboxDeadDiscard)
+}
+
+func connectOutboxDatabase() *pgx.Conn {
+	databaseURL := f.NewConfigLoader().String("DATABASE_URL", "")
+	if databaseURL == "" {
+		log.Fatal("`DATABASE_URL` environment variable is not set")
+	}
+
+	conn, err := pgx.Connect(context.Background(), databaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return conn
+}
+
+// outboxTables returns the outbox tables configured with `OUTBOX_SCHEMA`, `OUTBOX_TABLE` and `OUTBOX_DEAD_TABLE`.
+func outboxTables() outboxrepo.Tables {
+	return f.NewConfig().Outbox.Tables()
+}
+
+// notifyOutboxCouriers wakes up the outbox couriers with `OUTBOX_NOTIFY` enabled. Within a transaction, the
+// notification is delivered on commit.
+func notifyOutboxCouriers(q *outboxrepo.Queries) error {
+	outbox := f.NewConfig().Outbox
+	if !outbox.Notify {
+		return nil
+	}
+
+	return q.NotifyOutboxEvents(context.Background(), outbox.NotifyChannel())
+}
+
+// outboxEventID parses the ID of an outbox event given as an argument.
+func outboxEventID(arg string) int64 {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid event ID `%s`", arg)
+	}
+
+	return id
+}
+
+func runOutboxDeadEventCommand(arg, action string, run func(*outboxrepo.Queries, int64) (int64, error)) {
+	id := outboxEventID(arg)
+
+	conn := connectOutboxDatabase()
+	defer conn.Close(context.Background()) // nolint: errcheck
+
+	rows, err := run(outboxrepo.NewWithTables(conn, outboxTables()), id)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if rows == 0 {
+		log.Fatalf("Dead outbox event %d not found", id)
+	}
+
+	fmt.Printf("Dead outbox event %d %s\n", id, action)
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/spf13/cobra"
+
+	"github.com/foundation-go/foundation/outboxrepo"
+)
+
+var OutboxReplay = &cobra.Command{
+	Use:   "outbox:replay",
+	Short: "Replay published outbox events",
+	Long: "Put the published outbox events kept with `OUTBOX_RETENTION` back to the outbox to be published again, e.g.: " +
+		"`foundation outbox:replay --topic chats --from 2024-01-01T00:00:00Z`",
+	Run: func(cmd *cobra.Command, _ []string) {
+		params := outboxrepo.ReplayOutboxEventsParams{
+			CreatedFrom: timeFlag(cmd, "from"),
+			CreatedTo:   timeFlag(cmd, "to"),
+			Topic:       textFlag(cmd, "topic"),
+			Key:         textFlag(cmd, "key"),
+			ProtoName:   textFlag(cmd, "proto-name"),
+		}
+
+		if !params.CreatedFrom.Valid && !params.CreatedTo.Valid && !params.Topic.Valid && !params.Key.Valid && !params.ProtoName.Valid {
+			log.Fatal("You should set at least one of `--from`, `--to`, `--topic`, `--key` or `--proto-name` flags")
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		conn := connectOutboxDatabase()
+		defer conn.Close(context.Background()) // nolint: errcheck
+
+		tx, err := conn.Begin(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer tx.Rollback(context.Background()) // nolint: errcheck
+
+		queries := outboxrepo.NewWithTables(tx, outboxTables())
+
+		rows, err := queries.ReplayOutboxEvents(context.Background(), params)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if dryRun {
+			fmt.Printf("%d outbox events would be replayed\n", rows)
+			return
+		}
+
+		if rows > 0 {
+			if err = notifyOutboxCouriers(queries); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if err = tx.Commit(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%d outbox events replayed\n", rows)
+	},
+}
+
+func init() {
+	OutboxReplay.Flags().String("from", "", "Replay the events created at or after the given time (RFC 3339)")
+	OutboxReplay.Flags().String("to", "", "Replay the events created before the given time (RFC 3339)")
+	OutboxReplay.Flags().StringP("topic", "t", "", "Replay the events of the given topic")
+	OutboxReplay.Flags().StringP("key", "k", "", "Replay the events with the given key")
+	OutboxReplay.Flags().StringP("proto-name", "p", "", "Replay the events of the given proto message, e.g. `chats.MessageSent`")
+	OutboxReplay.Flags().Bool("dry-run", false, "Only print the number of events to replay")
+}
+
+func timeFlag(cmd *cobra.Command, name string) pgtype.Timestamptz {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return pgtype.Timestamptz{}
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Fatalf("Invalid `--%s` flag, expected RFC 3339 time: %v", name, err)
+	}
+
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func textFlag(cmd *cobra.Command, name string) pgtype.Text {
+	value, _ := cmd.Flags().GetString(name)
+
+	return pgtype.Text{String: value, Valid: value != ""}
+}
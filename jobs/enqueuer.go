@@ -2,10 +2,10 @@ package jobs
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/gocraft/work"
 	"github.com/gomodule/redigo/redis"
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -22,16 +22,16 @@ type Component struct {
 
 	redisPool *redis.Pool
 	namespace string
-	logger    *logrus.Entry
+	logger    *slog.Logger
 }
 
 // ComponentOption is an option to `Component`.
 type ComponentOption func(*Component)
 
 // WithLogger sets the logger for the JobsEnqueuer component
-func WithLogger(logger *logrus.Entry) ComponentOption {
+func WithLogger(logger *slog.Logger) ComponentOption {
 	return func(c *Component) {
-		c.logger = logger.WithField("component", c.Name())
+		c.logger = logger.With("component", c.Name())
 	}
 }
 
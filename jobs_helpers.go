@@ -1,27 +1,32 @@
 package foundation
 
 import (
-	"errors"
-
-	fjobs "github.com/foundation-go/foundation/jobs"
 	"github.com/getsentry/sentry-go"
 	"github.com/gocraft/work"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fjobs "github.com/foundation-go/foundation/jobs"
 )
 
-func (s *Service) GetJobsEnqueuer() *work.Enqueuer {
-	component := s.GetComponent(fjobs.ComponentName)
-	if component == nil {
-		err := errors.New("jobs enqueuer component is not registered")
-		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+// LookupJobsEnqueuer returns the jobs enqueuer, or an error if the jobs enqueuer component
+// is not registered.
+func (s *Service) LookupJobsEnqueuer() (*work.Enqueuer, ferr.FoundationError) {
+	comp, err := GetComponentAs[*fjobs.Component](s, fjobs.ComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get jobs enqueuer component")
 	}
 
-	comp, ok := component.(*fjobs.Component)
-	if !ok {
-		err := errors.New("jobs enqueuer component is not of type *foundation_jobs.Component")
+	return comp.Enqueuer, nil
+}
+
+// GetJobsEnqueuer returns the jobs enqueuer. It terminates the service if the jobs enqueuer
+// component is not registered, use `LookupJobsEnqueuer` to handle this case.
+func (s *Service) GetJobsEnqueuer() *work.Enqueuer {
+	enqueuer, err := s.LookupJobsEnqueuer()
+	if err != nil {
 		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+		logFatal(s.Logger, err.Error())
 	}
 
-	return comp.Enqueuer
+	return enqueuer
 }
@@ -23,9 +23,16 @@ type JobsWorker struct {
 	Options *JobsWorkerOptions
 }
 
-func InitJobsWorker(name string) *JobsWorker {
+func InitJobsWorker(name string, opts ...InitOption) *JobsWorker {
 	return &JobsWorker{
-		Service: Init(name),
+		Service: Init(name, opts...),
+	}
+}
+
+// NewJobsWorker returns a jobs worker mode running on the given service, see `Service.StartModes`.
+func NewJobsWorker(s *Service) *JobsWorker {
+	return &JobsWorker{
+		Service: s.newModeService(),
 	}
 }
 
@@ -58,13 +65,19 @@ func NewJobsWorkerOptions() *JobsWorkerOptions {
 
 // Start runs the worker that handles jobs
 func (w *JobsWorker) Start(opts *JobsWorkerOptions) {
+	w.Service.Start(w.Mode(opts))
+}
+
+// Mode returns the jobs worker mode to run with `Service.StartModes`.
+func (w *JobsWorker) Mode(opts *JobsWorkerOptions) *StartOptions {
 	w.Options = opts
 
-	w.Service.Start(&StartOptions{
+	return &StartOptions{
 		ModeName:               "jobs_worker",
 		StartComponentsOptions: append(w.Options.StartComponentsOptions, WithRedis()),
 		ServiceFunc:            w.ServiceFunc,
-	})
+		service:                w.Service,
+	}
 }
 
 func (w *JobsWorker) ServiceFunc(ctx context.Context) error {
@@ -109,13 +122,13 @@ func (w *JobsWorker) ServiceFunc(ctx context.Context) error {
 }
 
 func (w *JobsWorker) LoggingMiddleware(job *work.Job, next work.NextMiddlewareFunc) error {
-	w.Logger.Infof("Starting job %s", job.Name)
+	w.Logger.Info(fmt.Sprintf("Starting job %s", job.Name))
 
 	err := next()
 	if err != nil {
-		w.Logger.Errorf("Job %s failed: %v", job.Name, err)
+		w.Logger.Error(fmt.Sprintf("Job %s failed: %v", job.Name, err))
 	} else {
-		w.Logger.Infof("Job %s completed", job.Name)
+		w.Logger.Info(fmt.Sprintf("Job %s completed", job.Name))
 	}
 
 	return err
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
@@ -8,19 +9,39 @@ import (
 	"github.com/segmentio/kafka-go/sasl"
 	"github.com/segmentio/kafka-go/sasl/plain"
 	"github.com/segmentio/kafka-go/sasl/scram"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/segmentio/kafka-go"
-	"github.com/sirupsen/logrus"
 )
 
 const (
+	HeaderCausationID   = "causation-id"
 	HeaderCorrelationID = "correlation-id"
+	HeaderEventID       = "event-id"
+	HeaderOccurredAt    = "occurred-at"
+	HeaderOriginalTopic = "original-topic"
 	HeaderOriginatorID  = "originator-id"
+	HeaderProducer      = "producer"
+	HeaderProducerMode  = "producer-mode"
 	HeaderProtoName     = "proto-name"
+	HeaderSchemaVersion = "schema-version"
+
+	HeaderRetryAttempt   = "retry-attempt"
+	HeaderRetryError     = "retry-error"
+	HeaderRetryHandler   = "retry-handler"
+	HeaderRetryNotBefore = "retry-not-before"
+
+	HeaderDLQAttempts = "dlq-attempts"
+	HeaderDLQError    = "dlq-error"
+	HeaderDLQFailedAt = "dlq-failed-at"
+	HeaderDLQHandler  = "dlq-handler"
+	HeaderDLQService  = "dlq-service"
+	HeaderRedrivenFor = "redriven-for"
 )
 
 const (
@@ -28,14 +49,22 @@ const (
 	ProducerComponentName = "kafka-producer"
 )
 
+// BatchProducerBatchTimeout is the time the batch producer waits for the messages before sending them. The messages
+// passed to a single `WriteMessages` call are batched together regardless, so it is kept short.
+const BatchProducerBatchTimeout = 5 * time.Millisecond
+
 type ConsumerComponent struct {
 	Consumer *kafka.Reader
 
+	// RetryConsumers are the consumers of the retry topics by their suffix, see `WithConsumerRetryTopics`.
+	RetryConsumers map[string]*kafka.Reader
+
 	appName       string
 	brokers       []string
-	logger        *logrus.Entry
+	logger        *slog.Logger
 	saslMechanism sasl.Mechanism
 	topics        []string
+	retrySuffixes []string
 	tlsDir        string
 }
 
@@ -57,9 +86,9 @@ func WithConsumerBrokers(brokers []string) ConsumerComponentOption {
 }
 
 // WithConsumerLogger sets the logger for the ConsumerComponent
-func WithConsumerLogger(logger *logrus.Entry) ConsumerComponentOption {
+func WithConsumerLogger(logger *slog.Logger) ConsumerComponentOption {
 	return func(c *ConsumerComponent) {
-		c.logger = logger.WithField("component", ConsumerComponentName)
+		c.logger = logger.With("component", ConsumerComponentName)
 	}
 }
 
@@ -70,6 +99,15 @@ func WithConsumerTopics(topics []string) ConsumerComponentOption {
 	}
 }
 
+// WithConsumerRetryTopics sets the suffixes of the retry topics for the ConsumerComponent. The retry topics of
+// each suffix, e.g. `chats.retry.1m` for the `chats` topic and the `.retry.1m` suffix, are read by their own
+// consumer, in their own consumer group.
+func WithConsumerRetryTopics(suffixes []string) ConsumerComponentOption {
+	return func(c *ConsumerComponent) {
+		c.retrySuffixes = suffixes
+	}
+}
+
 // WithConsumerTLSDir sets the location of the TLS directory for the ConsumerComponent
 func WithConsumerTLSDir(tlsDir string) ConsumerComponentOption {
 	return func(c *ConsumerComponent) {
@@ -105,13 +143,13 @@ func (c *ConsumerComponent) Start() error {
 	if len(c.topics) == 0 {
 		return errors.New("you must specify topics during the application initialization using the `WithKafkaConsumerTopics`")
 	}
-	c.logger.Debugf("Kafka consumer topics: %v", c.topics)
+	c.logger.Debug(fmt.Sprintf("Kafka consumer topics: %v", c.topics))
 
 	config := kafka.ReaderConfig{
 		Brokers:     c.brokers,
 		GroupID:     fmt.Sprintf("%s-foundation", c.appName),
 		GroupTopics: c.topics,
-		ErrorLogger: c.logger,
+		ErrorLogger: printfLogger(c.logger, slog.LevelError),
 	}
 
 	dialer, err := newDialer(c.tlsDir, c.saslMechanism)
@@ -125,12 +163,31 @@ func (c *ConsumerComponent) Start() error {
 
 	c.Consumer = consumer
 
+	c.RetryConsumers = make(map[string]*kafka.Reader, len(c.retrySuffixes))
+	for _, suffix := range c.retrySuffixes {
+		retryConfig := config
+		retryConfig.GroupID = config.GroupID + suffix
+		retryConfig.GroupTopics = make([]string, 0, len(c.topics))
+		for _, topic := range c.topics {
+			retryConfig.GroupTopics = append(retryConfig.GroupTopics, topic+suffix)
+		}
+		// The retry topics may only be created once the first event is retried
+		retryConfig.WatchPartitionChanges = true
+
+		c.RetryConsumers[suffix] = kafka.NewReader(retryConfig)
+	}
+
 	return nil
 }
 
 // Stop implements the Component interface.
 func (c *ConsumerComponent) Stop() error {
-	return c.Consumer.Close()
+	errs := []error{c.Consumer.Close()}
+	for _, consumer := range c.RetryConsumers {
+		errs = append(errs, consumer.Close())
+	}
+
+	return errors.Join(errs...)
 }
 
 // Health implements the Component interface.
@@ -153,8 +210,13 @@ func (c *ConsumerComponent) Name() string {
 type ProducerComponent struct {
 	Producer *kafka.Writer
 
+	// BatchProducer is meant for writing batches of messages synchronously: the messages passed to a single
+	// `WriteMessages` call are sent in one request per partition, without waiting for `batchSize` messages
+	// or `batchTimeout`. It is used by the outbox courier.
+	BatchProducer *kafka.Writer
+
 	brokers       []string
-	logger        *logrus.Entry
+	logger        *slog.Logger
 	tlsDir        string
 	batchSize     int
 	batchTimeout  time.Duration
@@ -184,9 +246,9 @@ func WithProducerBrokers(brokers []string) ProducerComponentOption {
 }
 
 // WithProducerLogger sets the logger for the ProducerComponent
-func WithProducerLogger(logger *logrus.Entry) ProducerComponentOption {
+func WithProducerLogger(logger *slog.Logger) ProducerComponentOption {
 	return func(c *ProducerComponent) {
-		c.logger = logger.WithField("component", ProducerComponentName)
+		c.logger = logger.With("component", ProducerComponentName)
 	}
 }
 
@@ -234,19 +296,29 @@ func (c *ProducerComponent) Start() error {
 		AllowAutoTopicCreation: true,
 		BatchSize:              c.batchSize,
 		BatchTimeout:           c.batchTimeout,
-		Logger:                 c.logger,
+		Logger:                 printfLogger(c.logger, slog.LevelInfo),
 		Transport:              transport,
 		Balancer:               &kafka.Hash{}, // distribute messages to partitions based on the hash of the key, round-robin if no key
 	}
 
 	c.Producer = producer
 
+	c.BatchProducer = &kafka.Writer{
+		Addr:                   kafka.TCP(c.brokers...),
+		AllowAutoTopicCreation: true,
+		BatchSize:              math.MaxInt32, // bounded by the batch bytes
+		BatchTimeout:           BatchProducerBatchTimeout,
+		Logger:                 printfLogger(c.logger, slog.LevelInfo),
+		Transport:              transport,
+		Balancer:               &kafka.Hash{},
+	}
+
 	return nil
 }
 
 // Stop implements the Component interface.
 func (c *ProducerComponent) Stop() error {
-	return c.Producer.Close()
+	return errors.Join(c.Producer.Close(), c.BatchProducer.Close())
 }
 
 // Health implements the Component interface.
@@ -263,6 +335,38 @@ func (c *ProducerComponent) Name() string {
 	return ProducerComponentName
 }
 
+// NewDialerAndTransport returns the dialer to read messages and the transport to write them with the given TLS
+// directory and SASL credentials, the way the consumer and producer components do. The SASL mechanism is only
+// used when both the username and the password are set.
+func NewDialerAndTransport(tlsDir, protocol, username, password string) (*kafka.Dialer, *kafka.Transport, error) {
+	var (
+		mechanism sasl.Mechanism
+		err       error
+	)
+
+	if username != "" && password != "" {
+		if mechanism, err = newSASLMechanism(protocol, username, password); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dialer, err := newDialer(tlsDir, mechanism)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dialer == nil {
+		dialer = kafka.DefaultDialer
+	}
+
+	transport, err := newTransport(tlsDir, mechanism)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dialer, transport, nil
+}
+
 func newDialer(tlsDir string, saslMechanism sasl.Mechanism) (*kafka.Dialer, error) {
 	if tlsDir == "" && saslMechanism == nil {
 		return nil, nil
@@ -363,3 +467,10 @@ func newSASLMechanism(protocol, username, password string) (sasl.Mechanism, erro
 
 	return mechanism, err
 }
+
+// printfLogger returns the kafka-go logger writing the messages to the logger at the given level.
+func printfLogger(logger *slog.Logger, level slog.Level) kafka.Logger {
+	return kafka.LoggerFunc(func(msg string, args ...interface{}) {
+		logger.Log(context.Background(), level, fmt.Sprintf(msg, args...))
+	})
+}
@@ -1,11 +1,13 @@
 package foundation
 
 import (
-	"errors"
+	"fmt"
 
-	fkafka "github.com/foundation-go/foundation/kafka"
 	"github.com/getsentry/sentry-go"
 	"github.com/segmentio/kafka-go"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fkafka "github.com/foundation-go/foundation/kafka"
 )
 
 // NewMessageFromEvent creates a new Kafka message from a Foundation Outbox event
@@ -27,38 +29,75 @@ func NewMessageFromEvent(event *Event) (*kafka.Message, error) {
 	return message, nil
 }
 
+// LookupKafkaConsumer returns the Kafka consumer, or an error if the Kafka consumer component
+// is not registered.
+func (s *Service) LookupKafkaConsumer() (*kafka.Reader, ferr.FoundationError) {
+	consumer, err := GetComponentAs[*fkafka.ConsumerComponent](s, fkafka.ConsumerComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get Kafka consumer component")
+	}
+
+	return consumer.Consumer, nil
+}
+
+// GetKafkaConsumer returns the Kafka consumer. It terminates the service if the Kafka consumer
+// component is not registered, use `LookupKafkaConsumer` to handle this case.
 func (s *Service) GetKafkaConsumer() *kafka.Reader {
-	component := s.GetComponent(fkafka.ConsumerComponentName)
-	if component == nil {
-		err := errors.New("kafka consumer component is not registered")
+	consumer, err := s.LookupKafkaConsumer()
+	if err != nil {
 		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+		logFatal(s.Logger, err.Error())
 	}
 
-	consumer, ok := component.(*fkafka.ConsumerComponent)
-	if !ok {
-		err := errors.New("kafka consumer component is not of type *fkafka.ConsumerComponent")
-		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+	return consumer
+}
+
+// LookupKafkaProducer returns the Kafka producer, or an error if the Kafka producer component
+// is not registered.
+func (s *Service) LookupKafkaProducer() (*kafka.Writer, ferr.FoundationError) {
+	producer, err := GetComponentAs[*fkafka.ProducerComponent](s, fkafka.ProducerComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get Kafka producer component")
 	}
 
-	return consumer.Consumer
+	return producer.Producer, nil
 }
 
-func (s *Service) GetKafkaProducer() *kafka.Writer {
-	component := s.GetComponent(fkafka.ProducerComponentName)
-	if component == nil {
-		err := errors.New("kafka producer component is not registered")
-		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+// LookupKafkaRetryConsumer returns the Kafka consumer of the retry topics with the given suffix, or an error if
+// the Kafka consumer component is not registered or doesn't consume these retry topics.
+func (s *Service) LookupKafkaRetryConsumer(suffix string) (*kafka.Reader, ferr.FoundationError) {
+	consumer, err := GetComponentAs[*fkafka.ConsumerComponent](s, fkafka.ConsumerComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get Kafka consumer component")
 	}
 
-	producer, ok := component.(*fkafka.ProducerComponent)
+	retryConsumer, ok := consumer.RetryConsumers[suffix]
 	if !ok {
-		err := errors.New("kafka producer component is not of type *fkafka.ProducerComponent")
+		return nil, ferr.NewInternalError(fmt.Errorf("no consumer for the `%s` retry topics", suffix), "failed to get Kafka retry consumer")
+	}
+
+	return retryConsumer, nil
+}
+
+// LookupKafkaBatchProducer returns the Kafka producer writing batches of messages synchronously, see
+// `fkafka.ProducerComponent.BatchProducer`, or an error if the Kafka producer component is not registered.
+func (s *Service) LookupKafkaBatchProducer() (*kafka.Writer, ferr.FoundationError) {
+	producer, err := GetComponentAs[*fkafka.ProducerComponent](s, fkafka.ProducerComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get Kafka producer component")
+	}
+
+	return producer.BatchProducer, nil
+}
+
+// GetKafkaProducer returns the Kafka producer. It terminates the service if the Kafka producer
+// component is not registered, use `LookupKafkaProducer` to handle this case.
+func (s *Service) GetKafkaProducer() *kafka.Writer {
+	producer, err := s.LookupKafkaProducer()
+	if err != nil {
 		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+		logFatal(s.Logger, err.Error())
 	}
 
-	return producer.Producer
+	return producer
 }
@@ -0,0 +1,126 @@
+package foundation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc"
+)
+
+const (
+	ShutdownDefaultDrainDelay = 0
+	ShutdownDefaultTimeout    = 30
+)
+
+// LifecycleHook is a function executed on the service startup or shutdown.
+type LifecycleHook func(ctx context.Context) error
+
+// OnStart registers a hook to be executed after the components have been started and before
+// the service starts serving. Hooks are executed in the order they were registered, a failing
+// hook aborts the startup.
+func (s *Service) OnStart(hook LifecycleHook) {
+	root := s.root()
+	root.onStartHooks = append(root.onStartHooks, hook)
+}
+
+// OnShutdown registers a hook to be executed after the service has stopped serving and before
+// the components are stopped. Hooks are executed in the reverse order they were registered.
+func (s *Service) OnShutdown(hook LifecycleHook) {
+	root := s.root()
+	root.onShutdownHooks = append(root.onShutdownHooks, hook)
+}
+
+func (s *Service) runStartHooks(ctx context.Context) error {
+	for i, hook := range s.onStartHooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("start hook %d out of %d: %w", i+1, len(s.onStartHooks), err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) runShutdownHooks(ctx context.Context) {
+	for i := len(s.onShutdownHooks) - 1; i >= 0; i-- {
+		if err := s.onShutdownHooks[i](ctx); err != nil {
+			err = fmt.Errorf("shutdown hook %d out of %d: %w", i+1, len(s.onShutdownHooks), err)
+			sentry.CaptureException(err)
+			s.Logger.Error(err.Error())
+		}
+	}
+}
+
+// ShutdownContext returns a context that is done when the shutdown deadline is exceeded.
+// Servers should use it to limit their graceful shutdown.
+func (s *Service) ShutdownContext() context.Context {
+	root := s.root()
+	if root.shutdownCtx == nil {
+		return context.Background()
+	}
+
+	return root.shutdownCtx
+}
+
+// shutdown runs the shutdown phases: marks the service as not ready, waits for the drain
+// delay, stops the service function, runs the shutdown hooks and stops the components.
+// The process is forcibly terminated if the shutdown deadline is exceeded.
+func (s *Service) shutdown(stopService context.CancelFunc, waitService func() error) {
+	drainDelay := time.Duration(s.Config.Shutdown.DrainDelay) * time.Second
+	timeout := time.Duration(s.Config.Shutdown.Timeout) * time.Second
+
+	s.Logger.Info("Shutting down service...")
+
+	// Phase 1: stop receiving new traffic
+	s.shuttingDown.Store(true)
+
+	// Phase 2: let load balancers notice the service is not ready anymore
+	if drainDelay > 0 {
+		s.Logger.Info(fmt.Sprintf("Draining for %s...", drainDelay))
+		time.Sleep(drainDelay)
+	}
+
+	var cancel context.CancelFunc
+	s.shutdownCtx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	forceExit := time.AfterFunc(timeout, func() {
+		err := fmt.Errorf("shutdown deadline of %s exceeded, forcing exit", timeout)
+		sentry.CaptureException(err)
+		sentry.Flush(2 * time.Second)
+		s.Logger.Error(err.Error())
+		os.Exit(1)
+	})
+	defer forceExit.Stop()
+
+	// Phase 3: stop the servers and workers
+	stopService()
+	if err := waitService(); err != nil {
+		err = fmt.Errorf("failed to stop service: %w", err)
+		sentry.CaptureException(err)
+		s.Logger.Error(err.Error())
+	}
+
+	// Phase 4: run the application hooks and stop the components
+	s.runShutdownHooks(s.shutdownCtx)
+	s.StopComponents()
+}
+
+// gracefulStopGRPC gracefully stops the gRPC server, closing all the connections forcibly
+// once the shutdown deadline is exceeded.
+func (s *Service) gracefulStopGRPC(server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-s.ShutdownContext().Done():
+		s.Logger.Warn("gRPC server graceful stop timed out, closing connections")
+		server.Stop()
+	}
+}
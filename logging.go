@@ -1,36 +1,216 @@
 package foundation
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
+
+	fctx "github.com/foundation-go/foundation/context"
 )
 
-func initLogger(appName string) *log.Entry {
-	logger := log.New()
+const (
+	// LevelTrace is the level of the `trace` entries, below `slog.LevelDebug`.
+	LevelTrace = slog.LevelDebug - 4
+	// LevelFatal is the level of the entries logged before the service exits, above `slog.LevelError`.
+	LevelFatal = slog.LevelError + 4
+)
 
-	switch FoundationEnv() {
-	case EnvProduction:
-		logger.SetFormatter(&log.JSONFormatter{})
-	default:
-		logger.SetFormatter(&log.TextFormatter{
-			FullTimestamp: true,
-		})
+// WithLogHandler sets the slog handler the service logs are written with, e.g. `slog.NewJSONHandler`,
+// `NewLogrusHandler` or an adapter to zerolog. By default, logs are written as JSON in production and
+// as text otherwise. The records below `LOG_LEVEL` are skipped before reaching the handler.
+func WithLogHandler(handler slog.Handler) InitOption {
+	return func(s *Service) {
+		s.logHandler = handler
+	}
+}
+
+// initLogger returns the logger of the service writing to the given handler, or to the default one if nil.
+// The records below the level are skipped, and the context fields are attached to them, see `fctx.LogFields`.
+func initLogger(appName string, handler slog.Handler, level slog.Leveler) *slog.Logger {
+	if handler == nil {
+		opts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLogLevel}
+
+		switch FoundationEnv() {
+		case EnvProduction:
+			handler = slog.NewJSONHandler(os.Stderr, opts)
+		default:
+			handler = slog.NewTextHandler(os.Stderr, opts)
+		}
+	} else {
+		handler = &levelHandler{Handler: handler, level: level}
+	}
+
+	return slog.New(fctx.NewLogHandler(handler)).With("app", appName)
+}
+
+// parseLogLevel returns the level of the given `LOG_LEVEL`, e.g. `info`. The levels of logrus are supported
+// as well, `panic` being the same as `fatal`.
+func parseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "fatal", "panic":
+		return LevelFatal, nil
 	}
 
-	logLevel, err := log.ParseLevel(os.Getenv("LOG_LEVEL"))
-	if err != nil {
-		logLevel = log.InfoLevel
+	return 0, fmt.Errorf("not a valid log level: %q", name)
+}
+
+// logLevelName returns the `LOG_LEVEL` name of the level, e.g. `info`.
+func logLevelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelDebug:
+		return "trace"
+	case level >= LevelFatal:
+		return "fatal"
 	}
 
-	logger.SetLevel(logLevel)
+	return strings.ToLower(level.String())
+}
+
+// replaceLogLevel names the trace and fatal levels in the records of the default handlers.
+func replaceLogLevel(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 || attr.Key != slog.LevelKey {
+		return attr
+	}
+
+	if level, ok := attr.Value.Any().(slog.Level); ok && (level < slog.LevelDebug || level >= LevelFatal) {
+		attr.Value = slog.StringValue(strings.ToUpper(logLevelName(level)))
+	}
+
+	return attr
+}
+
+// logFatal logs the message at the fatal level, then exits.
+func logFatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Log(context.Background(), LevelFatal, msg, args...)
+	os.Exit(1)
+}
+
+// levelHandler filters the records by level before passing them to the handler, so that the level can be
+// changed at runtime (see the admin API).
+type levelHandler struct {
+	slog.Handler
+
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
 
-	return logger.WithField("app", appName)
+// NewLogrusHandler returns a slog handler writing the records to the given logrus logger, to keep the logrus
+// formatting and hooks with `WithLogHandler`.
+func NewLogrusHandler(entry *log.Entry) slog.Handler {
+	return &logrusHandler{entry: entry}
 }
 
-func (s *Service) logStartup() {
-	s.Logger.Infof("Starting service `%s`", s.Name)
-	s.Logger.Infof(" - Mode:        %s", s.ModeName)
-	s.Logger.Infof(" - Environment: %s", FoundationEnv())
-	s.Logger.Infof(" - Foundation:  v%s", Version)
+type logrusHandler struct {
+	entry  *log.Entry
+	prefix string
+}
+
+func (h *logrusHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.entry.Logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func (h *logrusHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make(log.Fields, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		addLogrusField(fields, h.prefix, attr)
+		return true
+	})
+
+	entry := h.entry.WithFields(fields)
+	if ctx != nil {
+		entry = entry.WithContext(ctx)
+	}
+	entry.Time = record.Time
+
+	entry.Log(logrusLevel(record.Level), record.Message)
+
+	return nil
+}
+
+func (h *logrusHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(log.Fields, len(attrs))
+	for _, attr := range attrs {
+		addLogrusField(fields, h.prefix, attr)
+	}
+
+	return &logrusHandler{entry: h.entry.WithFields(fields), prefix: h.prefix}
+}
+
+func (h *logrusHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &logrusHandler{entry: h.entry, prefix: h.prefix + name + "."}
+}
+
+func addLogrusField(fields log.Fields, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+
+		for _, groupAttr := range value.Group() {
+			addLogrusField(fields, prefix, groupAttr)
+		}
+
+		return
+	}
+
+	fields[prefix+attr.Key] = value.Any()
+}
+
+func logrusLevel(level slog.Level) log.Level {
+	switch {
+	case level >= LevelFatal:
+		return log.FatalLevel
+	case level >= slog.LevelError:
+		return log.ErrorLevel
+	case level >= slog.LevelWarn:
+		return log.WarnLevel
+	case level >= slog.LevelInfo:
+		return log.InfoLevel
+	case level >= slog.LevelDebug:
+		return log.DebugLevel
+	default:
+		return log.TraceLevel
+	}
+}
+
+func (s *Service) logStartup(modeNames []string) {
+	s.Logger.Info(fmt.Sprintf("Starting service `%s`", s.Name))
+	if len(modeNames) > 1 {
+		s.Logger.Info(fmt.Sprintf(" - Modes:       %s", strings.Join(modeNames, ", ")))
+	} else {
+		s.Logger.Info(fmt.Sprintf(" - Mode:        %s", s.ModeName))
+	}
+	s.Logger.Info(fmt.Sprintf(" - Environment: %s", FoundationEnv()))
+	s.Logger.Info(fmt.Sprintf(" - Foundation:  v%s", Version))
 }
@@ -0,0 +1,119 @@
+package foundation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	fctx "github.com/foundation-go/foundation/context"
+)
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	logger := initLogger("test", slog.NewJSONHandler(&buf, nil), &level)
+
+	userID := uuid.New()
+	ctx := fctx.WithCorrelationID(context.Background(), "correlation")
+	ctx = fctx.WithUserID(ctx, userID)
+
+	logger.With("chat", 42).InfoContext(ctx, "Message sent")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, but got %q: %v", buf.String(), err)
+	}
+
+	for key, expected := range map[string]interface{}{
+		"msg":            "Message sent",
+		"level":          "INFO",
+		"app":            "test",
+		"chat":           float64(42),
+		"correlation_id": "correlation",
+		"user_id":        userID.String(),
+	} {
+		if record[key] != expected {
+			t.Errorf("Expected `%s` to be %v, but got %v", key, expected, record[key])
+		}
+	}
+
+	// The level is changed at runtime
+	buf.Reset()
+	level.Set(slog.LevelWarn)
+	logger.InfoContext(ctx, "Skipped")
+	logger.WarnContext(ctx, "Written")
+
+	if strings.Contains(buf.String(), "Skipped") || !strings.Contains(buf.String(), "Written") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+}
+
+func TestGetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := initLogger("test", slog.NewJSONHandler(&buf, nil), new(slog.LevelVar))
+
+	ctx := fctx.WithCorrelationID(context.Background(), "correlation")
+	ctx = fctx.WithLogger(ctx, logger)
+
+	// The context fields attached by `GetLogger` are not attached again when logging with the context
+	fctx.GetLogger(ctx).InfoContext(ctx, "Request started")
+
+	if count := strings.Count(buf.String(), `"correlation_id"`); count != 1 {
+		t.Errorf("Expected the correlation ID once, but got %d times: %s", count, buf.String())
+	}
+}
+
+func TestLogrusHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(&buf)
+	logrusLogger.SetFormatter(&logrus.JSONFormatter{})
+
+	logger := initLogger("test", NewLogrusHandler(logrus.NewEntry(logrusLogger)), new(slog.LevelVar))
+
+	ctx := fctx.WithMode(context.Background(), "events_worker")
+	logger.InfoContext(ctx, "Received", slog.Group("event", "name", "chats.MessageSent"))
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, but got %q: %v", buf.String(), err)
+	}
+
+	for key, expected := range map[string]interface{}{
+		"msg":        "Received",
+		"level":      "info",
+		"app":        "test",
+		"mode":       "events_worker",
+		"event.name": "chats.MessageSent",
+	} {
+		if record[key] != expected {
+			t.Errorf("Expected `%s` to be %v, but got %v", key, expected, record[key])
+		}
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for name, expected := range map[string]string{
+		"trace":   "trace",
+		"DEBUG":   "debug",
+		"INFO":    "info",
+		"warning": "warn",
+		"error":   "error",
+		"panic":   "fatal",
+	} {
+		level, err := parseLogLevel(name)
+		if err != nil || logLevelName(level) != expected {
+			t.Errorf("Expected %q to be parsed as %q, but got %q, %v", name, expected, logLevelName(level), err)
+		}
+	}
+
+	if _, err := parseLogLevel("loud"); err == nil {
+		t.Error("Expected an invalid log level to be rejected")
+	}
+}
@@ -3,30 +3,37 @@ package foundation
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/sirupsen/logrus"
 )
 
 const (
 	MetricsServerComponentName = "metrics-server"
+
+	// MetricsDefaultHealthCheckTimeout is the default timeout of a single health check, in seconds.
+	MetricsDefaultHealthCheckTimeout = 5
 )
 
 type MetricsServerComponent struct {
-	healthHandler http.HandlerFunc
-	logger        *logrus.Entry
-	port          int
-	server        *http.Server
+	healthHandler    http.HandlerFunc
+	livenessHandler  http.HandlerFunc
+	readinessHandler http.HandlerFunc
+	startupHandler   http.HandlerFunc
+	adminHandler     http.Handler
+	logger           *slog.Logger
+	port             int
+	server           *http.Server
 }
 
 type MetricsServerComponentOption func(*MetricsServerComponent)
 
 // WithMetricsServerLogger sets the logger for the MetricsServer component.
-func WithMetricsServerLogger(logger *logrus.Entry) MetricsServerComponentOption {
+func WithMetricsServerLogger(logger *slog.Logger) MetricsServerComponentOption {
 	return func(c *MetricsServerComponent) {
-		c.logger = logger.WithField("component", c.Name())
+		c.logger = logger.With("component", c.Name())
 	}
 }
 
@@ -44,6 +51,34 @@ func WithMetricsServerHealthHandler(handler http.HandlerFunc) MetricsServerCompo
 	}
 }
 
+// WithMetricsServerLivenessHandler sets the `/health/live` handler for the MetricsServer component.
+func WithMetricsServerLivenessHandler(handler http.HandlerFunc) MetricsServerComponentOption {
+	return func(c *MetricsServerComponent) {
+		c.livenessHandler = handler
+	}
+}
+
+// WithMetricsServerReadinessHandler sets the `/health/ready` handler for the MetricsServer component.
+func WithMetricsServerReadinessHandler(handler http.HandlerFunc) MetricsServerComponentOption {
+	return func(c *MetricsServerComponent) {
+		c.readinessHandler = handler
+	}
+}
+
+// WithMetricsServerStartupHandler sets the `/health/startup` handler for the MetricsServer component.
+func WithMetricsServerStartupHandler(handler http.HandlerFunc) MetricsServerComponentOption {
+	return func(c *MetricsServerComponent) {
+		c.startupHandler = handler
+	}
+}
+
+// WithMetricsServerAdminHandler sets the `/admin/` handler for the MetricsServer component.
+func WithMetricsServerAdminHandler(handler http.Handler) MetricsServerComponentOption {
+	return func(c *MetricsServerComponent) {
+		c.adminHandler = handler
+	}
+}
+
 func NewMetricsServerComponent(opts ...MetricsServerComponentOption) *MetricsServerComponent {
 	c := &MetricsServerComponent{
 		port: 51077,
@@ -54,8 +89,20 @@ func NewMetricsServerComponent(opts ...MetricsServerComponentOption) *MetricsSer
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/health", c.healthHandler)
+	for path, handler := range map[string]http.HandlerFunc{
+		"/health":         c.healthHandler,
+		"/health/live":    c.livenessHandler,
+		"/health/ready":   c.readinessHandler,
+		"/health/startup": c.startupHandler,
+	} {
+		if handler != nil {
+			mux.HandleFunc(path, handler)
+		}
+	}
 	mux.Handle("/metrics", promhttp.Handler())
+	if c.adminHandler != nil {
+		mux.Handle("/admin/", c.adminHandler)
+	}
 
 	c.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", c.port),
@@ -67,13 +114,13 @@ func NewMetricsServerComponent(opts ...MetricsServerComponentOption) *MetricsSer
 
 // Start implements the Component interface.
 func (c *MetricsServerComponent) Start() error {
-	c.logger.Infof("Exposing metrics HTTP server on http://0.0.0.0:%d", c.port)
+	c.logger.Info(fmt.Sprintf("Exposing metrics HTTP server on http://0.0.0.0:%d", c.port))
 
 	go func() {
 		if err := c.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			err = fmt.Errorf("failed to start metrics server: %w", err)
 			sentry.CaptureException(err)
-			c.logger.Fatal(err)
+			logFatal(c.logger, err.Error())
 		}
 	}()
 
@@ -0,0 +1,196 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/foundation-go/foundation/outboxrepo"
+)
+
+// FrameworkMigrationsTable is the table tracking the framework migrations, separate from the `schema_migrations`
+// table of the service migrations. With a custom `OUTBOX_TABLE`, it is named after that table instead.
+const FrameworkMigrationsTable = "foundation_schema_migrations"
+
+// MigrateFramework applies the migrations shipped with Foundation, such as the outbox tables, to the database.
+// The outbox tables are created in the schema and with the names and partitioning of the given configuration,
+// the defaults are used if it is nil. Concurrent runs, e.g. from several replicas starting with `WithAutoMigrate`,
+// are serialized with PostgreSQL advisory locks.
+func MigrateFramework(databaseURL string, outbox *OutboxConfig) error {
+	if outbox == nil {
+		outbox = &OutboxConfig{Table: outboxrepo.DefaultEventsTable, DeadTable: outboxrepo.DefaultDeadEventsTable}
+	}
+
+	ctx := context.Background()
+
+	// Both the URL and the key=value forms are supported, as by the PostgreSQL component
+	config, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	defer conn.Close(ctx) // nolint: errcheck
+
+	if err = prepareOutboxTables(ctx, conn, outbox); err != nil {
+		return fmt.Errorf("failed to prepare outbox tables: %w", err)
+	}
+
+	source, err := iofs.New(renamedFS{
+		fsys:     outboxrepo.Migrations,
+		replacer: outboxrepo.Tables{Events: outbox.Table, DeadEvents: outbox.DeadTable}.Replacer(),
+	}, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read framework migrations: %w", err)
+	}
+
+	migrationsTable := FrameworkMigrationsTable
+	if outbox.Table != outboxrepo.DefaultEventsTable {
+		migrationsTable = outbox.Table + "_schema_migrations"
+	}
+
+	db := stdlib.OpenDB(*migrationsConnConfig(config, outbox))
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{MigrationsTable: migrationsTable, SchemaName: outbox.Schema})
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("failed to initialize framework migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("failed to initialize framework migrations: %w", err)
+	}
+	defer m.Close() // nolint: errcheck
+
+	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply framework migrations: %w", err)
+	}
+
+	if outbox.Partitioning != "" {
+		if err = createOutboxPartitions(ctx, conn, outbox, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrationsConnConfig returns the configuration of the connection applying the migrations, resolving
+// the outbox tables in the outbox schema.
+func migrationsConnConfig(config *pgx.ConnConfig, outbox *OutboxConfig) *pgx.ConnConfig {
+	config = config.Copy()
+	if outbox.Schema != "" {
+		config.RuntimeParams["search_path"] = outbox.Schema
+	}
+
+	return config
+}
+
+// prepareOutboxTables creates what the migrations expect to exist: the outbox schema, and the partitioned
+// events table if the partitioning is enabled.
+func prepareOutboxTables(ctx context.Context, conn *pgx.Conn, outbox *OutboxConfig) error {
+	if outbox.Schema == "" && outbox.Partitioning == "" {
+		return nil
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", outbox.qualify(outbox.Table)); err != nil {
+		return err
+	}
+
+	if outbox.Schema != "" {
+		if _, err = tx.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+outbox.Schema); err != nil {
+			return err
+		}
+	}
+
+	if outbox.Partitioning != "" {
+		if err = createOutboxPartitionedTable(ctx, tx, outbox); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// renamedFS renames the outbox tables in the migrations read from the files.
+type renamedFS struct {
+	fsys     fs.FS
+	replacer *strings.Replacer
+}
+
+func (r renamedFS) Open(name string) (fs.File, error) {
+	f, err := r.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close() // nolint: errcheck
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Directories are listed by the migrations source
+	if info.IsDir() {
+		return r.fsys.Open(name)
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &renamedFile{Reader: strings.NewReader(r.replacer.Replace(string(data))), info: info}, nil
+}
+
+type renamedFile struct {
+	*strings.Reader
+	info fs.FileInfo
+}
+
+func (f *renamedFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *renamedFile) Close() error {
+	return nil
+}
+
+// WithAutoMigrate applies the framework migrations at startup, see `MigrateFramework`.
+func WithAutoMigrate() StartComponentsOption {
+	return func(s *Service) {
+		s.Config.Database.AutoMigrate = true
+	}
+}
+
+// autoMigrate applies the framework migrations if `WithAutoMigrate` is set.
+func (s *Service) autoMigrate() error {
+	if !s.Config.Database.AutoMigrate {
+		return nil
+	}
+
+	s.Logger.Info("Applying framework migrations")
+
+	return MigrateFramework(s.Config.Database.URL, s.Config.Outbox)
+}
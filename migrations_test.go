@@ -0,0 +1,101 @@
+package foundation
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foundation-go/foundation/outboxrepo"
+)
+
+func TestFrameworkMigrations(t *testing.T) {
+	source, err := iofs.New(outboxrepo.Migrations, "migrations")
+	if err != nil {
+		t.Fatalf("Expected the framework migrations to be embedded, but got %v", err)
+	}
+	defer source.Close() // nolint: errcheck
+
+	count := 0
+	for version, err := source.First(); err == nil; version, err = source.Next(version) {
+		count++
+
+		up, _, err := source.ReadUp(version)
+		if err != nil {
+			t.Errorf("Expected migration %d to have an up file, but got %v", version, err)
+		} else {
+			_ = up.Close()
+		}
+
+		down, _, err := source.ReadDown(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected migration %d to have a down file", version)
+		} else if err == nil {
+			_ = down.Close()
+		}
+	}
+
+	if count == 0 {
+		t.Error("Expected framework migrations, but got none")
+	}
+}
+
+func TestRenamedFS(t *testing.T) {
+	fsys := renamedFS{
+		fsys:     outboxrepo.Migrations,
+		replacer: outboxrepo.Tables{Events: "outbox", DeadEvents: "outbox_dead"}.Replacer(),
+	}
+
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("Expected to list the migrations, but got %v", err)
+	}
+
+	for _, entry := range entries {
+		f, err := fsys.Open("migrations/" + entry.Name())
+		if err != nil {
+			t.Fatalf("Expected to open %s, but got %v", entry.Name(), err)
+		}
+
+		data, _ := io.ReadAll(f)
+		_ = f.Close()
+
+		if strings.Contains(string(data), "foundation_outbox") {
+			t.Errorf("Expected the tables to be renamed in %s, but got:\n%s", entry.Name(), data)
+		}
+	}
+}
+
+func TestMigrationsConnConfig(t *testing.T) {
+	for _, databaseURL := range []string{
+		"postgres://foundation:secret@db:5433/chats?sslmode=disable",
+		"host=db port=5433 user=foundation password=secret dbname=chats sslmode=disable",
+	} {
+		config, err := pgx.ParseConfig(databaseURL)
+		if err != nil {
+			t.Fatalf("Expected to parse %q, but got %v", databaseURL, err)
+		}
+
+		migrations := migrationsConnConfig(config, &OutboxConfig{Schema: "outbox"})
+		if migrations.Host != "db" || migrations.Port != 5433 || migrations.User != "foundation" || migrations.Password != "secret" || migrations.Database != "chats" {
+			t.Errorf("Unexpected connection config for %q: %+v", databaseURL, migrations.Config)
+		}
+		if migrations.RuntimeParams["search_path"] != "outbox" {
+			t.Errorf("Expected the outbox schema in the search path for %q, but got %v", databaseURL, migrations.RuntimeParams)
+		}
+		if _, ok := config.RuntimeParams["search_path"]; ok {
+			t.Errorf("Expected the parsed config of %q to be left unchanged", databaseURL)
+		}
+	}
+}
+
+func TestMigrateFrameworkInvalidDatabaseURL(t *testing.T) {
+	err := MigrateFramework("host=db port=invalid", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse database URL") {
+		t.Errorf("Expected a database URL parsing error, but got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package foundation
+
+// newModeService returns a service to run a mode on, sharing the components, configuration
+// and lifecycle with the given service.
+func (s *Service) newModeService() *Service {
+	root := s.root()
+
+	return &Service{
+		Name:   root.Name,
+		Config: root.Config,
+		Logger: root.Logger,
+		parent: root,
+	}
+}
+
+// root returns the service owning the components and the lifecycle.
+func (s *Service) root() *Service {
+	if s.parent != nil {
+		return s.parent
+	}
+
+	return s
+}
+
+// triggerShutdown initiates the graceful shutdown of the service.
+func (s *Service) triggerShutdown() {
+	if cancel := s.root().cancelFunc; cancel != nil {
+		cancel()
+	}
+}
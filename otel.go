@@ -2,6 +2,7 @@ package foundation
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -26,7 +27,7 @@ func (s *Service) initTracing() func() {
 	otlpEndpoint = strings.TrimPrefix(otlpEndpoint, "http://")
 	otlpEndpoint = strings.TrimPrefix(otlpEndpoint, "https://")
 
-	s.Logger.Infof("OTEL_EXPORTER_OTLP_ENDPOINT: %s", otlpEndpoint)
+	s.Logger.Info(fmt.Sprintf("OTEL_EXPORTER_OTLP_ENDPOINT: %s", otlpEndpoint))
 
 	// Create OTLP exporter
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -37,7 +38,7 @@ func (s *Service) initTracing() func() {
 		otlptracegrpc.WithTimeout(2*time.Second),
 	)
 	if err != nil {
-		s.Logger.Errorf("failed to create OTLP exporter: %v", err)
+		s.Logger.Error(fmt.Sprintf("failed to create OTLP exporter: %v", err))
 		return func() {}
 	}
 
@@ -45,7 +46,7 @@ func (s *Service) initTracing() func() {
 	bsp := sdktrace.NewBatchSpanProcessor(exporter)
 
 	ratio := getTracingRatio()
-	s.Logger.Infof("Tracing ratio: %f", ratio)
+	s.Logger.Info(fmt.Sprintf("Tracing ratio: %f", ratio))
 
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithSpanProcessor(bsp),
@@ -65,7 +66,7 @@ func (s *Service) initTracing() func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := tp.Shutdown(ctx); err != nil {
-			s.Logger.Errorf("failed to shut down tracer provider: %v", err)
+			s.Logger.Error(fmt.Sprintf("failed to shut down tracer provider: %v", err))
 		}
 	}
 }
@@ -3,12 +3,16 @@ package foundation
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/foundation-go/foundation/outboxrepo"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/segmentio/kafka-go"
 	"google.golang.org/protobuf/proto"
 
 	fctx "github.com/foundation-go/foundation/context"
@@ -16,6 +20,9 @@ import (
 	fkafka "github.com/foundation-go/foundation/kafka"
 )
 
+// EventDefaultSchemaVersion is the schema version of the events that do not set one.
+const EventDefaultSchemaVersion = "1"
+
 // Event represents an event to be published to the outbox
 type Event struct {
 	Topic     string
@@ -23,7 +30,19 @@ type Event struct {
 	Payload   []byte
 	ProtoName string
 	Headers   map[string]string
+	// CreatedAt is the time the event occurred at
 	CreatedAt time.Time
+
+	// ID is the unique identifier of the event, a UUIDv7
+	ID string
+	// CausationID is the ID of the event or the request that caused the event
+	CausationID string
+	// Producer is the name of the service that produced the event
+	Producer string
+	// ProducerMode is the running mode of the service that produced the event
+	ProducerMode string
+	// SchemaVersion is the version of the event payload schema, `EventDefaultSchemaVersion` if empty
+	SchemaVersion string
 }
 
 // Unmarshal unmarshals the event payload into a protobuf message
@@ -51,25 +70,97 @@ func NewEventFromProto(msg proto.Message, key string, headers map[string]string)
 		headers = make(map[string]string)
 	}
 
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to generate event ID")
+	}
+
 	return &Event{
-		Topic:     topic,
-		Key:       key,
-		Payload:   payload,
-		ProtoName: protoName,
-		Headers:   headers,
-		CreatedAt: time.Now(),
+		Topic:         topic,
+		Key:           key,
+		Payload:       payload,
+		ProtoName:     protoName,
+		Headers:       headers,
+		CreatedAt:     time.Now(),
+		ID:            id.String(),
+		SchemaVersion: EventDefaultSchemaVersion,
 	}, nil
 }
 
-func addDefaultHeaders(ctx context.Context, event *Event) *Event {
+// newEventFromHeaders creates an event, reading its metadata from the headers it was published with.
+func newEventFromHeaders(topic, key string, payload []byte, headers map[string]string, createdAt time.Time) *Event {
+	// Prefer the time the event occurred at over the time it was written
+	if occurredAt, err := time.Parse(time.RFC3339Nano, headers[fkafka.HeaderOccurredAt]); err == nil {
+		createdAt = occurredAt
+	}
+
+	return &Event{
+		Topic:         topic,
+		Key:           key,
+		Payload:       payload,
+		ProtoName:     headers[fkafka.HeaderProtoName],
+		Headers:       headers,
+		CreatedAt:     createdAt,
+		ID:            headers[fkafka.HeaderEventID],
+		CausationID:   headers[fkafka.HeaderCausationID],
+		Producer:      headers[fkafka.HeaderProducer],
+		ProducerMode:  headers[fkafka.HeaderProducerMode],
+		SchemaVersion: headers[fkafka.HeaderSchemaVersion],
+	}
+}
+
+// addDefaultHeaders fills in the missing event metadata and sets it to the event headers.
+func (s *Service) addDefaultHeaders(ctx context.Context, event *Event) (*Event, ferr.FoundationError) {
 	if event.Headers == nil {
 		event.Headers = make(map[string]string)
 	}
 
+	if event.ID == "" {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, ferr.NewInternalError(err, "failed to generate event ID")
+		}
+		event.ID = id.String()
+	}
+
+	if event.CausationID == "" {
+		event.CausationID = fctx.GetCausationID(ctx)
+	}
+
+	if event.Producer == "" {
+		event.Producer = s.Name
+	}
+
+	if event.ProducerMode == "" {
+		event.ProducerMode = fctx.GetMode(ctx)
+	}
+	if event.ProducerMode == "" {
+		event.ProducerMode = s.ModeName
+	}
+
+	if event.SchemaVersion == "" {
+		event.SchemaVersion = EventDefaultSchemaVersion
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
 	event.Headers[fkafka.HeaderProtoName] = event.ProtoName
 	event.Headers[fkafka.HeaderCorrelationID] = fctx.GetCorrelationID(ctx)
+	event.Headers[fkafka.HeaderEventID] = event.ID
+	event.Headers[fkafka.HeaderCausationID] = event.CausationID
+	event.Headers[fkafka.HeaderProducer] = event.Producer
+	event.Headers[fkafka.HeaderProducerMode] = event.ProducerMode
+	event.Headers[fkafka.HeaderOccurredAt] = event.CreatedAt.UTC().Format(time.RFC3339Nano)
+	event.Headers[fkafka.HeaderSchemaVersion] = event.SchemaVersion
+
+	return event, nil
+}
 
-	return event
+// outboxQueries returns the outbox queries running against the configured tables, see `OutboxConfig`.
+func (s *Service) outboxQueries(db outboxrepo.DBTX) *outboxrepo.Queries {
+	return outboxrepo.NewWithTables(db, s.Config.Outbox.Tables())
 }
 
 // publishEventToOutbox publishes an event to the outbox.
@@ -81,8 +172,13 @@ func (s *Service) publishEventToOutbox(ctx context.Context, event *Event, tx pgx
 	)
 
 	if tx == nil {
+		pool, fErr := s.LookupPostgreSQL()
+		if fErr != nil {
+			return fErr
+		}
+
 		// Start transaction
-		tx, err = s.GetPostgreSQL().Begin(ctx)
+		tx, err = pool.Begin(ctx)
 		if err != nil {
 			return ferr.NewInternalError(err, "failed to begin transaction")
 		}
@@ -96,7 +192,7 @@ func (s *Service) publishEventToOutbox(ctx context.Context, event *Event, tx pgx
 		return ferr.NewInternalError(err, "failed to marshal headers")
 	}
 
-	queries := outboxrepo.New(tx)
+	queries := s.outboxQueries(tx)
 	params := outboxrepo.CreateOutboxEventParams{
 		Topic:   event.Topic,
 		Key:     event.Key,
@@ -108,12 +204,22 @@ func (s *Service) publishEventToOutbox(ctx context.Context, event *Event, tx pgx
 		return ferr.NewInternalError(err, "failed to insert event into outbox")
 	}
 
+	// Wake up the outbox couriers, the notification is delivered on commit
+	if s.Config.Outbox.Notify {
+		if err = queries.NotifyOutboxEvents(ctx, s.Config.Outbox.NotifyChannel()); err != nil {
+			return ferr.NewInternalError(err, "failed to notify outbox couriers")
+		}
+	}
+
 	if commitNeeded {
 		if err = tx.Commit(ctx); err != nil {
 			return ferr.NewInternalError(err, "failed to commit transaction")
 		}
 	}
 
+	// Counted even if the given transaction is rolled back afterwards
+	outboxEventsWritten.WithLabelValues(event.Topic).Inc()
+
 	return nil
 }
 
@@ -124,17 +230,120 @@ func (s *Service) publishEventToKafka(ctx context.Context, event *Event) ferr.Fo
 		return ferr.NewInternalError(err, "failed to create message from event")
 	}
 
-	if err := s.GetKafkaProducer().WriteMessages(ctx, *message); err != nil {
+	producer, fErr := s.LookupKafkaProducer()
+	if fErr != nil {
+		return fErr
+	}
+
+	if err := producer.WriteMessages(ctx, *message); err != nil {
 		return ferr.NewInternalError(err, "failed to publish event to Kafka")
 	}
 
 	return nil
 }
 
+// errEarlierEventFailed is reported for the events not published because an earlier event with
+// the same topic and key failed to publish.
+var errEarlierEventFailed = errors.New("an earlier event with the same key failed to publish")
+
+// publishEventsToKafka publishes the events to Kafka with as few `WriteMessages` calls as possible, and returns
+// the error of each event, if any. The events with the same topic and key are kept in order: each of them is only
+// written once the previous one succeeded, otherwise it fails with `errEarlierEventFailed`.
+func (s *Service) publishEventsToKafka(ctx context.Context, events []*Event) []error {
+	errs := make([]error, len(events))
+
+	producer, fErr := s.LookupKafkaBatchProducer()
+	if fErr != nil {
+		for i := range errs {
+			errs[i] = fErr
+		}
+
+		return errs
+	}
+
+	failedKeys := make(map[eventKey]bool)
+	for _, round := range eventRounds(events) {
+		indexes := make([]int, 0, len(round))
+		messages := make([]kafka.Message, 0, len(round))
+
+		for _, i := range round {
+			event := events[i]
+			if failedKeys[eventKey{topic: event.Topic, key: event.Key}] {
+				errs[i] = errEarlierEventFailed
+				continue
+			}
+
+			message, err := NewMessageFromEvent(event)
+			if err != nil {
+				errs[i] = ferr.NewInternalError(err, "failed to create message from event")
+				failedKeys[eventKey{topic: event.Topic, key: event.Key}] = true
+				continue
+			}
+
+			indexes = append(indexes, i)
+			messages = append(messages, *message)
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		err := producer.WriteMessages(ctx, messages...)
+
+		var writeErrs kafka.WriteErrors
+		isWriteErrs := errors.As(err, &writeErrs)
+
+		for j, i := range indexes {
+			messageErr := err
+			if isWriteErrs {
+				messageErr = writeErrs[j]
+			}
+
+			if messageErr != nil {
+				errs[i] = ferr.NewInternalError(messageErr, "failed to publish event to Kafka")
+				failedKeys[eventKey{topic: events[i].Topic, key: events[i].Key}] = true
+			}
+		}
+	}
+
+	return errs
+}
+
+// eventKey identifies the events to keep in order, as they are written to the same partition.
+type eventKey struct {
+	topic string
+	key   string
+}
+
+// eventRounds splits the events into rounds of indexes to write one after another, each round taking
+// the next event of every key. Events without a key aren't ordered, so they all go to the first round.
+func eventRounds(events []*Event) [][]int {
+	rounds := make([][]int, 0, 1)
+	keyRounds := make(map[eventKey]int)
+	for i, event := range events {
+		round := 0
+		if event.Key != "" {
+			key := eventKey{topic: event.Topic, key: event.Key}
+			round = keyRounds[key]
+			keyRounds[key]++
+		}
+
+		if round == len(rounds) {
+			rounds = append(rounds, nil)
+		}
+		rounds[round] = append(rounds[round], i)
+	}
+
+	return rounds
+}
+
 // PublishEvent publishes an event to the outbox, starting a new transaction,
 // or straight to the Kafka topic if `OUTBOX_ENABLED` is not set.
 func (s *Service) PublishEvent(ctx context.Context, event *Event, tx pgx.Tx) ferr.FoundationError {
-	event = addDefaultHeaders(ctx, event)
+	event, fErr := s.addDefaultHeaders(ctx, event)
+	if fErr != nil {
+		return fErr
+	}
 
 	if s.Config.Outbox.Enabled {
 		return s.publishEventToOutbox(ctx, event, tx)
@@ -156,8 +365,13 @@ func (s *Service) NewAndPublishEvent(ctx context.Context, msg proto.Message, key
 // WithTransaction executes the given function in a transaction. If the function
 // returns an event, it will be published.
 func (s *Service) WithTransaction(ctx context.Context, f func(tx pgx.Tx) ([]*Event, ferr.FoundationError)) ferr.FoundationError {
+	pool, fErr := s.LookupPostgreSQL()
+	if fErr != nil {
+		return fErr
+	}
+
 	// Start transaction
-	tx, err := s.GetPostgreSQL().Begin(ctx)
+	tx, err := pool.Begin(ctx)
 	if err != nil {
 		return ferr.NewInternalError(err, "failed to begin transaction")
 	}
@@ -191,7 +405,7 @@ func (s *Service) WithTransaction(ctx context.Context, f func(tx pgx.Tx) ([]*Eve
 
 // ListOutboxEvents returns a list of outbox events in the order they were created.
 func (s *Service) ListOutboxEvents(ctx context.Context, tx pgx.Tx, limit int32) ([]outboxrepo.FoundationOutboxEvent, ferr.FoundationError) {
-	queries := outboxrepo.New(tx)
+	queries := s.outboxQueries(tx)
 
 	events, err := queries.ListOutboxEvents(ctx, limit)
 	if err != nil {
@@ -201,9 +415,26 @@ func (s *Service) ListOutboxEvents(ctx context.Context, tx pgx.Tx, limit int32)
 	return events, nil
 }
 
+// ClaimOutboxEvents locks and returns a batch of outbox events in the order they were created, skipping
+// the events claimed by other transactions, so that several outbox couriers can run in parallel without
+// publishing an event twice. The events stay claimed until the transaction ends.
+func (s *Service) ClaimOutboxEvents(ctx context.Context, tx pgx.Tx, limit int32) ([]outboxrepo.FoundationOutboxEvent, ferr.FoundationError) {
+	queries := s.outboxQueries(tx)
+
+	events, err := queries.ClaimOutboxEvents(ctx, limit)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to `ClaimOutboxEvents`")
+	}
+
+	return events, nil
+}
+
 // DeleteOutboxEvents deletes outbox events up to (and including) the given ID.
+//
+// Deprecated: events with lower IDs committed late, or claimed by other couriers, would be deleted as well.
+// Use `DeleteOutboxEventsByIDs` instead.
 func (s *Service) DeleteOutboxEvents(ctx context.Context, tx pgx.Tx, maxID int64) ferr.FoundationError {
-	queries := outboxrepo.New(tx)
+	queries := s.outboxQueries(tx)
 
 	if err := queries.DeleteOutboxEvents(ctx, maxID); err != nil {
 		return ferr.NewInternalError(err, "failed to `DeleteOutboxEvents`")
@@ -212,6 +443,98 @@ func (s *Service) DeleteOutboxEvents(ctx context.Context, tx pgx.Tx, maxID int64
 	return nil
 }
 
+// DeleteOutboxEventsByIDs deletes the outbox events with the given IDs.
+func (s *Service) DeleteOutboxEventsByIDs(ctx context.Context, tx pgx.Tx, ids []int64) ferr.FoundationError {
+	queries := s.outboxQueries(tx)
+
+	if err := queries.DeleteOutboxEventsByIDs(ctx, ids); err != nil {
+		return ferr.NewInternalError(err, "failed to `DeleteOutboxEventsByIDs`")
+	}
+
+	return nil
+}
+
+// MarkOutboxEventsPublished marks the outbox events with the given IDs as published, see `OUTBOX_RETENTION`.
+func (s *Service) MarkOutboxEventsPublished(ctx context.Context, tx pgx.Tx, ids []int64) ferr.FoundationError {
+	queries := s.outboxQueries(tx)
+
+	if err := queries.MarkOutboxEventsPublished(ctx, ids); err != nil {
+		return ferr.NewInternalError(err, "failed to `MarkOutboxEventsPublished`")
+	}
+
+	return nil
+}
+
+// PurgeOutboxEvents deletes the outbox events published before the given time and returns their number.
+func (s *Service) PurgeOutboxEvents(ctx context.Context, publishedBefore time.Time) (int64, ferr.FoundationError) {
+	pool, fErr := s.LookupPostgreSQL()
+	if fErr != nil {
+		return 0, fErr
+	}
+
+	rows, err := s.outboxQueries(pool).PurgeOutboxEvents(ctx, pgtype.Timestamptz{Time: publishedBefore, Valid: true})
+	if err != nil {
+		return 0, ferr.NewInternalError(err, "failed to `PurgeOutboxEvents`")
+	}
+
+	return rows, nil
+}
+
+// ListOutboxDeadEvents returns the outbox events that failed to publish after all the retry attempts.
+func (s *Service) ListOutboxDeadEvents(ctx context.Context, limit int32) ([]outboxrepo.FoundationOutboxDeadEvent, ferr.FoundationError) {
+	pool, fErr := s.LookupPostgreSQL()
+	if fErr != nil {
+		return nil, fErr
+	}
+
+	events, err := s.outboxQueries(pool).ListOutboxDeadEvents(ctx, limit)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to `ListOutboxDeadEvents`")
+	}
+
+	return events, nil
+}
+
+// RequeueOutboxDeadEvent moves the dead event back to the outbox to be published again. It returns
+// false if there is no dead event with the given ID.
+func (s *Service) RequeueOutboxDeadEvent(ctx context.Context, id int64) (bool, ferr.FoundationError) {
+	pool, fErr := s.LookupPostgreSQL()
+	if fErr != nil {
+		return false, fErr
+	}
+
+	queries := s.outboxQueries(pool)
+
+	rows, err := queries.RequeueOutboxDeadEvent(ctx, id)
+	if err != nil {
+		return false, ferr.NewInternalError(err, "failed to `RequeueOutboxDeadEvent`")
+	}
+
+	// Wake up the outbox couriers
+	if rows > 0 && s.Config.Outbox.Notify {
+		if err = queries.NotifyOutboxEvents(ctx, s.Config.Outbox.NotifyChannel()); err != nil {
+			return true, ferr.NewInternalError(err, "failed to notify outbox couriers")
+		}
+	}
+
+	return rows > 0, nil
+}
+
+// DiscardOutboxDeadEvent deletes the dead event. It returns false if there is no dead event with the given ID.
+func (s *Service) DiscardOutboxDeadEvent(ctx context.Context, id int64) (bool, ferr.FoundationError) {
+	pool, fErr := s.LookupPostgreSQL()
+	if fErr != nil {
+		return false, fErr
+	}
+
+	rows, err := s.outboxQueries(pool).DeleteOutboxDeadEvent(ctx, id)
+	if err != nil {
+		return false, ferr.NewInternalError(err, "failed to `DeleteOutboxDeadEvent`")
+	}
+
+	return rows > 0, nil
+}
+
 // TODO: extract these functions to a more appropriate place
 func ProtoNameToTopic(protoName string) string {
 	// TODO: Respect `EVENTS_WORKER_ERRORS_TOPIC` for Foundation errors
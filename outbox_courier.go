@@ -3,33 +3,91 @@ package foundation
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
-	fctx "github.com/foundation-go/foundation/context"
 	ferr "github.com/foundation-go/foundation/errors"
-	fkafka "github.com/foundation-go/foundation/kafka"
+	"github.com/foundation-go/foundation/outboxrepo"
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const (
 	OutboxDefaultBatchSize = 100
 	OutboxDefaultInterval  = time.Second * 1
+
+	OutboxDefaultFallbackInterval = time.Second * 30
+	OutboxDefaultPurgeInterval    = time.Hour
+
+	OutboxDefaultMaxAttempts    = 10
+	OutboxDefaultInitialBackoff = time.Second * 1
+	OutboxDefaultMaxBackoff     = time.Minute * 10
 )
 
+// OutboxCourier is a mode in which events are read from the outbox and published to Kafka.
+//
+// Several couriers can run in parallel: each batch of events is claimed with `FOR UPDATE SKIP LOCKED`,
+// so an event is published by a single courier. The claimed events behind an earlier unpublished event with
+// the same key claimed by another courier are left in the outbox until it is published, so the events with
+// the same key are still published in order.
+//
+// Each batch is written to Kafka at once, keeping the events with the same topic and key in order. An event that
+// fails to publish is retried with an exponential backoff, holding back the later events with the same key but
+// not the other ones. Once the retry attempts are exhausted, it is moved to the `foundation_outbox_dead_events`
+// table, and the events behind it are published.
+//
+// With `OUTBOX_NOTIFY` enabled, the courier doesn't poll the outbox every `Interval`: it is woken up by the
+// notifications sent on new events, and only polls every `FallbackInterval` for missed notifications.
+//
+// With `OUTBOX_RETENTION` set, the published events are marked with `published_at` instead of being deleted,
+// so that they can be replayed with `foundation outbox:replay`. The courier purges them every `PurgeInterval`
+// once they are older than the retention.
+//
+// With `OUTBOX_PARTITIONING` set, the courier also creates the partitions of the events table ahead of time,
+// and drops the past ones once all their events are published and older than the retention.
 type OutboxCourier struct {
 	*SpinWorker
+
+	notifications chan struct{}
+	listener      sync.Once
+
+	lastMaintenance time.Time
 }
 
 // OutboxCourierOptions represents the options for starting an outbox courier
 type OutboxCourierOptions struct {
-	Interval               time.Duration
-	BatchSize              int32
-	ModeName               string
+	Interval  time.Duration
+	BatchSize int32
+	ModeName  string
+
+	// FallbackInterval is the interval to poll the outbox at when the notifications are enabled
+	// with `OUTBOX_NOTIFY`, in case some of them are missed. Default: 30s.
+	FallbackInterval time.Duration
+
+	// PurgeInterval is the interval to purge the published events older than `OUTBOX_RETENTION`, and to
+	// create and drop the partitions with `OUTBOX_PARTITIONING`, at. Default: 1h.
+	PurgeInterval time.Duration
+
+	// RetryPolicy describes how an event failed to publish is retried. Once `Attempts` are exhausted,
+	// the event is dead-lettered. Default: 10 attempts, backoff from 1s up to 10m.
+	RetryPolicy *RetryPolicy
+
 	StartComponentsOptions []StartComponentsOption
 }
 
-func InitOutboxCourier(name string) *OutboxCourier {
+func InitOutboxCourier(name string, opts ...InitOption) *OutboxCourier {
+	return &OutboxCourier{
+		SpinWorker: InitSpinWorker(name, opts...),
+	}
+}
+
+// NewOutboxCourier returns an outbox courier mode running on the given service, see `Service.StartModes`.
+func NewOutboxCourier(s *Service) *OutboxCourier {
 	return &OutboxCourier{
-		SpinWorker: InitSpinWorker(name),
+		SpinWorker: NewSpinWorker(s),
 	}
 }
 
@@ -38,11 +96,24 @@ func NewOutboxCourierOptions() *OutboxCourierOptions {
 		Interval:  OutboxDefaultInterval,
 		BatchSize: OutboxDefaultBatchSize,
 		ModeName:  "outbox_courier",
+
+		FallbackInterval: OutboxDefaultFallbackInterval,
+		PurgeInterval:    OutboxDefaultPurgeInterval,
+		RetryPolicy: &RetryPolicy{
+			Attempts:       OutboxDefaultMaxAttempts,
+			InitialBackoff: OutboxDefaultInitialBackoff,
+			MaxBackoff:     OutboxDefaultMaxBackoff,
+		},
 	}
 }
 
 // Start runs the outbox courier
 func (o *OutboxCourier) Start(outboxOpts *OutboxCourierOptions) {
+	o.Service.Start(o.Mode(outboxOpts))
+}
+
+// Mode returns the outbox courier mode to run with `Service.StartModes`.
+func (o *OutboxCourier) Mode(outboxOpts *OutboxCourierOptions) *StartOptions {
 	if outboxOpts.BatchSize == 0 {
 		outboxOpts.BatchSize = OutboxDefaultBatchSize
 	}
@@ -55,69 +126,395 @@ func (o *OutboxCourier) Start(outboxOpts *OutboxCourierOptions) {
 		outboxOpts.Interval = OutboxDefaultInterval
 	}
 
+	if outboxOpts.FallbackInterval == 0 {
+		outboxOpts.FallbackInterval = OutboxDefaultFallbackInterval
+	}
+
+	if outboxOpts.PurgeInterval == 0 {
+		outboxOpts.PurgeInterval = OutboxDefaultPurgeInterval
+	}
+
+	if outboxOpts.RetryPolicy == nil {
+		outboxOpts.RetryPolicy = NewOutboxCourierOptions().RetryPolicy
+	}
+
 	startOpts := NewSpinWorkerOptions()
 	startOpts.ModeName = outboxOpts.ModeName
-	startOpts.ProcessFunc = o.newProcessFunc(outboxOpts.BatchSize)
+	startOpts.ProcessFunc = o.newProcessFunc(outboxOpts.BatchSize, outboxOpts.RetryPolicy)
 	startOpts.Interval = outboxOpts.Interval
+
+	if o.Config.Outbox.Notify {
+		startOpts.ProcessFunc = o.newNotifiedProcessFunc(outboxOpts.BatchSize, outboxOpts.RetryPolicy, outboxOpts.Interval, outboxOpts.FallbackInterval)
+		startOpts.Interval = 0
+	}
+
+	if o.Config.Outbox.Retention > 0 || o.Config.Outbox.Partitioning != "" {
+		process := startOpts.ProcessFunc
+		startOpts.ProcessFunc = func(ctx context.Context) ferr.FoundationError {
+			o.maintainOutbox(ctx, outboxOpts.PurgeInterval)
+
+			return process(ctx)
+		}
+	}
+
 	startOpts.StartComponentsOptions = append(outboxOpts.StartComponentsOptions,
 		WithKafkaProducer(),
 	)
 
-	o.SpinWorker.Start(startOpts)
+	o.registerOutboxCollector()
+
+	return o.SpinWorker.Mode(startOpts)
 }
 
-func (o *OutboxCourier) newProcessFunc(batchSize int32) func(ctx context.Context) ferr.FoundationError {
+func (o *OutboxCourier) newProcessFunc(batchSize int32, retryPolicy *RetryPolicy) func(ctx context.Context) ferr.FoundationError {
 	return func(ctx context.Context) ferr.FoundationError {
-		pool := o.GetPostgreSQL()
-		tx, err := pool.Begin(ctx)
-		if err != nil {
-			return ferr.NewInternalError(err, "failed to begin transaction")
+		_, _, err := o.processBatch(ctx, batchSize, retryPolicy)
+
+		return err
+	}
+}
+
+// newNotifiedProcessFunc returns a process function publishing the outbox events as long as there are
+// full batches of them, then waiting for a notification, the next attempt of the failed events or the fallback
+// interval, see `notifiedWait`.
+func (o *OutboxCourier) newNotifiedProcessFunc(batchSize int32, retryPolicy *RetryPolicy, interval, fallbackInterval time.Duration) func(ctx context.Context) ferr.FoundationError {
+	o.notifications = make(chan struct{}, 1)
+
+	return func(ctx context.Context) ferr.FoundationError {
+		o.listener.Do(func() {
+			go o.listen(ctx)
+		})
+
+		claimed, heldBack, err := o.processBatch(ctx, batchSize, retryPolicy)
+		if err == nil && heldBack == 0 && claimed == int(batchSize) {
+			return nil
 		}
 
-		defer tx.Rollback(ctx) // nolint: errcheck
+		var nextAttemptAt time.Time
+		if err == nil && heldBack == 0 {
+			nextAttemptAt, err = o.nextOutboxAttemptAt(ctx)
+		}
 
-		outboxEvents, err := o.ListOutboxEvents(ctx, tx, batchSize)
-		if err != nil {
-			return ferr.NewInternalError(err, "failed to list outbox events")
+		o.waitNotification(ctx, notifiedWait(err != nil, heldBack > 0, nextAttemptAt, interval, fallbackInterval))
+
+		return err
+	}
+}
+
+// notifiedWait returns the time to wait for a notification before publishing the outbox events again. The couriers
+// are not notified once the events failed to publish are due, or once the held back events can be published: it
+// waits until the next attempt then, at most the fallback interval, or the regular interval after a failure or
+// when events are held back.
+func notifiedWait(failed, heldBack bool, nextAttemptAt time.Time, interval, fallbackInterval time.Duration) time.Duration {
+	if failed || heldBack {
+		return interval
+	}
+
+	if nextAttemptAt.IsZero() {
+		return fallbackInterval
+	}
+
+	return min(max(time.Until(nextAttemptAt), interval), fallbackInterval)
+}
+
+// nextOutboxAttemptAt returns the time of the next attempt to publish the events that failed to, if any.
+func (o *OutboxCourier) nextOutboxAttemptAt(ctx context.Context) (time.Time, ferr.FoundationError) {
+	pool, fErr := o.LookupPostgreSQL()
+	if fErr != nil {
+		return time.Time{}, fErr
+	}
+
+	nextAttemptAt, err := o.outboxQueries(pool).GetOutboxNextAttemptAt(ctx)
+	if err != nil {
+		return time.Time{}, ferr.NewInternalError(err, "failed to `GetOutboxNextAttemptAt`")
+	}
+
+	return nextAttemptAt.Time, nil
+}
+
+// waitNotification blocks until a new outbox event is notified, the timeout expires or the context is done.
+func (o *OutboxCourier) waitNotification(ctx context.Context, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-o.notifications:
+	case <-timer.C:
+	}
+}
+
+// notify wakes up the courier, without blocking if it is already due to wake up.
+func (o *OutboxCourier) notify() {
+	select {
+	case o.notifications <- struct{}{}:
+	default:
+	}
+}
+
+// listen listens to the outbox notifications on a dedicated connection until the context is done,
+// reconnecting on failures.
+func (o *OutboxCourier) listen(ctx context.Context) {
+	for {
+		err := o.listenNotifications(ctx)
+		if ctx.Err() != nil {
+			return
 		}
 
-		if len(outboxEvents) == 0 {
-			o.Logger.Debug("no outbox events to publish")
-			return nil
+		o.Logger.Error("Outbox notifications listener failed, reconnecting", "error", err)
+		sentry.CaptureException(err)
+
+		// Poll the outbox in case a notification was missed while reconnecting
+		o.notify()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
 		}
+	}
+}
 
-		maxId := outboxEvents[len(outboxEvents)-1].ID
-		for _, outboxEvent := range outboxEvents {
-			headers := make(map[string]string)
-			if err = json.Unmarshal(outboxEvent.Headers, &headers); err != nil {
-				return ferr.NewInternalError(err, "failed to unmarshal headers")
-			}
+// listenNotifications forwards the outbox notifications to the courier until the connection fails
+// or the context is done.
+func (o *OutboxCourier) listenNotifications(ctx context.Context) error {
+	pool, fErr := o.LookupPostgreSQL()
+	if fErr != nil {
+		return fErr
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection is kept out of the pool, as it is subscribed to the channel
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background()) // nolint: errcheck
+
+	channel := o.Config.Outbox.NotifyChannel()
+	if _, err = pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	o.Logger.Debug(fmt.Sprintf("Listening to the outbox notifications on `%s`", channel))
+
+	for {
+		if _, err = pgConn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+
+		o.notify()
+	}
+}
+
+// processBatch publishes a batch of outbox events and returns the number of events claimed, and of the ones
+// held back behind the events claimed by other couriers.
+func (o *OutboxCourier) processBatch(ctx context.Context, batchSize int32, retryPolicy *RetryPolicy) (int, int, ferr.FoundationError) {
+	pool, fErr := o.LookupPostgreSQL()
+	if fErr != nil {
+		return 0, 0, fErr
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, ferr.NewInternalError(err, "failed to begin transaction")
+	}
+
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	// Claim the events, so that concurrent couriers skip them
+	outboxEvents, err := o.ClaimOutboxEvents(ctx, tx, batchSize)
+	if err != nil {
+		return 0, 0, ferr.NewInternalError(err, "failed to claim outbox events")
+	}
+
+	if len(outboxEvents) == 0 {
+		o.Logger.Debug("no outbox events to publish")
+		return 0, 0, nil
+	}
+
+	// Leave the events behind an earlier one with the same key claimed by another courier, so that they are
+	// published after it
+	heldBack, err := o.heldBackOutboxEvents(ctx, tx, outboxEvents)
+	if err != nil {
+		return 0, 0, ferr.NewInternalError(err, "failed to list held back outbox events")
+	}
+
+	started := time.Now()
+
+	failedKeys := make(map[eventKey]bool)
+	claimed := make([]outboxrepo.FoundationOutboxEvent, 0, len(outboxEvents))
+	events := make([]*Event, 0, len(outboxEvents))
+	for _, outboxEvent := range outboxEvents {
+		key := eventKey{topic: outboxEvent.Topic, key: outboxEvent.Key}
 
-			event := &Event{
-				Topic:     outboxEvent.Topic,
-				Key:       outboxEvent.Key,
-				Payload:   outboxEvent.Payload,
-				ProtoName: headers[fkafka.HeaderProtoName],
-				CreatedAt: outboxEvent.CreatedAt.Time,
-				Headers:   headers,
+		// Leave the event in the outbox, behind the failed one with the same key
+		if outboxEvent.Key != "" && failedKeys[key] || heldBack[outboxEvent.ID] {
+			continue
+		}
+
+		headers := make(map[string]string)
+		if err = json.Unmarshal(outboxEvent.Headers, &headers); err != nil {
+			if err = o.failOutboxEvent(ctx, tx, outboxEvent, err, retryPolicy); err != nil {
+				return 0, 0, ferr.NewInternalError(err, "failed to record outbox event failure")
 			}
 
-			if err = o.PublishEvent(fctx.WithCorrelationID(ctx, headers[fkafka.HeaderCorrelationID]), event, tx); err != nil {
-				return ferr.NewInternalError(err, "failed to publish event")
+			failedKeys[key] = true
+			continue
+		}
+
+		claimed = append(claimed, outboxEvent)
+		events = append(events, newEventFromHeaders(
+			outboxEvent.Topic, outboxEvent.Key, outboxEvent.Payload, headers, outboxEvent.CreatedAt.Time,
+		))
+	}
+
+	// Publish the whole batch at once
+	ids := make([]int64, 0, len(events))
+	topics := make([]string, 0, len(events))
+	for i, publishErr := range o.publishEventsToKafka(ctx, events) {
+		switch {
+		case publishErr == nil:
+			ids = append(ids, claimed[i].ID)
+			topics = append(topics, claimed[i].Topic)
+		case errors.Is(publishErr, errEarlierEventFailed):
+			// Left in the outbox, see above
+		default:
+			if err = o.failOutboxEvent(ctx, tx, claimed[i], publishErr, retryPolicy); err != nil {
+				return 0, 0, ferr.NewInternalError(err, "failed to record outbox event failure")
 			}
 		}
+	}
 
-		if err = o.DeleteOutboxEvents(ctx, tx, maxId); err != nil {
-			return ferr.NewInternalError(err, "failed to delete outbox events")
+	if len(ids) > 0 && o.Config.Outbox.Retention > 0 {
+		if err = o.MarkOutboxEventsPublished(ctx, tx, ids); err != nil {
+			return 0, 0, ferr.NewInternalError(err, "failed to mark outbox events as published")
 		}
+	} else if len(ids) > 0 {
+		if err = o.DeleteOutboxEventsByIDs(ctx, tx, ids); err != nil {
+			return 0, 0, ferr.NewInternalError(err, "failed to delete outbox events")
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, 0, ferr.NewInternalError(err, "failed to commit transaction")
+	}
+
+	outboxBatchDuration.Observe(time.Since(started).Seconds())
+	for _, topic := range topics {
+		outboxEventsPublished.WithLabelValues(topic).Inc()
+	}
 
-		err = tx.Commit(ctx)
+	o.Logger.Debug(fmt.Sprintf("%d outbox events have published successfully", len(ids)))
+
+	return len(outboxEvents), len(heldBack), nil
+}
+
+// heldBackOutboxEvents returns the IDs of the claimed events with an earlier unpublished event with the same topic
+// and key which is not claimed along with them, i.e. is claimed by another courier.
+func (o *OutboxCourier) heldBackOutboxEvents(ctx context.Context, tx pgx.Tx, outboxEvents []outboxrepo.FoundationOutboxEvent) (map[int64]bool, error) {
+	ids := make([]int64, 0, len(outboxEvents))
+	for _, outboxEvent := range outboxEvents {
+		ids = append(ids, outboxEvent.ID)
+	}
+
+	heldBackIDs, err := o.outboxQueries(tx).ListHeldBackOutboxEvents(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	heldBack := make(map[int64]bool, len(heldBackIDs))
+	for _, id := range heldBackIDs {
+		heldBack[id] = true
+	}
+
+	return heldBack, nil
+}
+
+// maintainOutbox purges the published events older than the retention and manages the partitions, at most once
+// per interval. Failures are reported, but don't stop the courier from publishing.
+func (o *OutboxCourier) maintainOutbox(ctx context.Context, interval time.Duration) {
+	if time.Since(o.lastMaintenance) < interval {
+		return
+	}
+
+	o.lastMaintenance = time.Now()
+
+	retention := time.Duration(o.Config.Outbox.Retention) * time.Second
+
+	if o.Config.Outbox.Retention > 0 {
+		purged, err := o.PurgeOutboxEvents(ctx, time.Now().Add(-retention))
 		if err != nil {
-			return ferr.NewInternalError(err, "failed to commit transaction")
+			o.Logger.Error("Failed to purge published outbox events", "error", err)
+			sentry.CaptureException(err)
+		} else {
+			o.Logger.Debug(fmt.Sprintf("%d published outbox events have been purged", purged))
 		}
+	}
 
-		o.Logger.Debugf("%d outbox events have published successfully", len(outboxEvents))
+	if o.Config.Outbox.Partitioning != "" {
+		if err := o.maintainOutboxPartitions(ctx, time.Now().Add(-retention)); err != nil {
+			o.Logger.Error("Failed to maintain outbox partitions", "error", err)
+			sentry.CaptureException(err)
+		}
+	}
+}
+
+// maintainOutboxPartitions creates the upcoming partitions and drops the ones ended before the given time.
+func (o *OutboxCourier) maintainOutboxPartitions(ctx context.Context, before time.Time) error {
+	pool, fErr := o.LookupPostgreSQL()
+	if fErr != nil {
+		return fErr
+	}
+
+	if err := createOutboxPartitions(ctx, pool, o.Config.Outbox, time.Now()); err != nil {
+		return err
+	}
 
-		return nil
+	dropped, err := dropOutboxPartitions(ctx, pool, o.Config.Outbox, before)
+	if err != nil {
+		return err
 	}
+
+	o.Logger.Debug(fmt.Sprintf("%d outbox partitions have been dropped", dropped))
+
+	return nil
+}
+
+// failOutboxEvent schedules the next attempt to publish the event, or moves it to the dead events
+// once the retry attempts are exhausted.
+func (o *OutboxCourier) failOutboxEvent(ctx context.Context, tx pgx.Tx, outboxEvent outboxrepo.FoundationOutboxEvent, publishErr error, retryPolicy *RetryPolicy) error {
+	attempt := int(outboxEvent.Attempts) + 1
+
+	log := o.Logger.With(
+		"error", publishErr,
+		"outbox_event_id", outboxEvent.ID,
+		"topic", outboxEvent.Topic,
+		"attempt", attempt,
+	)
+	sentry.CaptureException(publishErr)
+	outboxEventsFailed.WithLabelValues(outboxEvent.Topic).Inc()
+
+	queries := o.outboxQueries(tx)
+
+	if attempt >= retryPolicy.Attempts {
+		log.Error("Failed to publish outbox event, moving it to the dead events")
+		outboxEventsDead.WithLabelValues(outboxEvent.Topic).Inc()
+
+		return queries.MoveOutboxEventToDead(ctx, outboxrepo.MoveOutboxEventToDeadParams{
+			ID:        outboxEvent.ID,
+			LastError: publishErr.Error(),
+		})
+	}
+
+	nextAttemptAt := time.Now().Add(retryPolicy.backoff(attempt + 1))
+	log.Warn(fmt.Sprintf("Failed to publish outbox event, retrying at %s", nextAttemptAt.Format(time.RFC3339)))
+
+	return queries.MarkOutboxEventFailed(ctx, outboxrepo.MarkOutboxEventFailedParams{
+		LastError:     publishErr.Error(),
+		NextAttemptAt: pgtype.Timestamptz{Time: nextAttemptAt, Valid: true},
+		ID:            outboxEvent.ID,
+	})
 }
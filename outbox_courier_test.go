@@ -0,0 +1,61 @@
+package foundation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOutboxCourierWaitNotification(t *testing.T) {
+	o := &OutboxCourier{notifications: make(chan struct{}, 1)}
+
+	// Notifications are coalesced without blocking
+	o.notify()
+	o.notify()
+
+	started := time.Now()
+	o.waitNotification(context.Background(), time.Minute)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected to be woken up by the notification, but waited %s", elapsed)
+	}
+
+	// Without notifications, the courier waits for the timeout
+	started = time.Now()
+	o.waitNotification(context.Background(), 50*time.Millisecond)
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait for the timeout, but waited %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	started = time.Now()
+	o.waitNotification(ctx, time.Minute)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected to stop waiting on the context cancellation, but waited %s", elapsed)
+	}
+}
+
+func TestOutboxCourierNotifiedWait(t *testing.T) {
+	interval, fallbackInterval := time.Second, 30*time.Second
+
+	for _, tc := range []struct {
+		name          string
+		failed        bool
+		heldBack      bool
+		nextAttemptAt time.Time
+		min, max      time.Duration
+	}{
+		{name: "idle", min: fallbackInterval, max: fallbackInterval},
+		{name: "failed", failed: true, min: interval, max: interval},
+		{name: "held back", heldBack: true, nextAttemptAt: time.Now().Add(time.Hour), min: interval, max: interval},
+		{name: "retried soon", nextAttemptAt: time.Now().Add(5 * time.Second), min: 4 * time.Second, max: 5 * time.Second},
+		{name: "retry due", nextAttemptAt: time.Now().Add(-time.Second), min: interval, max: interval},
+		{name: "retried later", nextAttemptAt: time.Now().Add(time.Hour), min: fallbackInterval, max: fallbackInterval},
+	} {
+		wait := notifiedWait(tc.failed, tc.heldBack, tc.nextAttemptAt, interval, fallbackInterval)
+		if wait < tc.min || wait > tc.max {
+			t.Errorf("%s: expected to wait between %s and %s, but got %s", tc.name, tc.min, tc.max, wait)
+		}
+	}
+}
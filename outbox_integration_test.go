@@ -0,0 +1,419 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/foundation-go/foundation/outboxrepo"
+	fpg "github.com/foundation-go/foundation/postgresql"
+)
+
+// newTestOutboxCourier returns an outbox courier running against the outbox tables migrated in a new schema
+// of the `FOUNDATION_TEST_DATABASE_URL` database, and skips the test if it is not set.
+func newTestOutboxCourier(t *testing.T) *OutboxCourier {
+	t.Helper()
+
+	return newTestPartitionedOutboxCourier(t, "")
+}
+
+// newTestPartitionedOutboxCourier is `newTestOutboxCourier` with the outbox events table partitioned.
+func newTestPartitionedOutboxCourier(t *testing.T, partitioning string) *OutboxCourier {
+	t.Helper()
+
+	databaseURL := os.Getenv("FOUNDATION_TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("FOUNDATION_TEST_DATABASE_URL is not set")
+	}
+
+	outbox := &OutboxConfig{
+		Schema:       fmt.Sprintf("foundation_test_%d", time.Now().UnixNano()),
+		Table:        outboxrepo.DefaultEventsTable,
+		DeadTable:    outboxrepo.DefaultDeadEventsTable,
+		Partitioning: partitioning,
+	}
+	if err := MigrateFramework(databaseURL, outbox); err != nil {
+		t.Fatalf("Failed to migrate the outbox tables: %v", err)
+	}
+
+	logger := initLogger("test", nil, new(slog.LevelVar))
+	pg := fpg.NewComponent(fpg.WithDatabaseURL(databaseURL), fpg.WithLogger(logger), fpg.WithPoolSize(4))
+	if err := pg.Start(); err != nil {
+		t.Fatalf("Failed to connect to the database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_, _ = pg.Pool().Exec(context.Background(), "DROP SCHEMA "+outbox.Schema+" CASCADE")
+		_ = pg.Stop()
+	})
+
+	return &OutboxCourier{SpinWorker: &SpinWorker{Service: &Service{
+		Config:     &Config{Outbox: outbox},
+		Logger:     logger,
+		Components: []Component{pg},
+	}}}
+}
+
+// createTestOutboxEvents inserts an event per key in the outbox and returns their IDs.
+func createTestOutboxEvents(t *testing.T, o *OutboxCourier, topic string, keys ...string) []int64 {
+	t.Helper()
+
+	ids := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		var id int64
+		err := o.GetPostgreSQL().QueryRow(context.Background(),
+			"INSERT INTO "+o.Config.Outbox.Tables().Events+" (topic, key, payload, headers, created_at) "+
+				"VALUES ($1, $2, $3, '{}', NOW()) RETURNING id",
+			topic, key, []byte(key),
+		).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to create outbox event: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// claimTestOutboxEvents claims the events in a new transaction, kept open until the end of the test.
+func claimTestOutboxEvents(t *testing.T, o *OutboxCourier, limit int32) (pgx.Tx, []int64) {
+	t.Helper()
+
+	tx, err := o.GetPostgreSQL().Begin(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = tx.Rollback(context.Background())
+	})
+
+	events, fErr := o.ClaimOutboxEvents(context.Background(), tx, limit)
+	if fErr != nil {
+		t.Fatalf("Failed to claim outbox events: %v", fErr)
+	}
+
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	return tx, ids
+}
+
+func TestOutboxClaimEvents(t *testing.T) {
+	o := newTestOutboxCourier(t)
+	ctx := context.Background()
+
+	ids := createTestOutboxEvents(t, o, "chats", "a", "a", "b", "", "c", "c")
+
+	// The first event of `a` fails, so the next one is held back until it is published
+	tx, err := o.GetPostgreSQL().Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	events, fErr := o.ClaimOutboxEvents(ctx, tx, 1)
+	if fErr != nil || len(events) != 1 || events[0].ID != ids[0] {
+		t.Fatalf("Expected to claim the first event, but got %v, %v", events, fErr)
+	}
+	if err = o.failOutboxEvent(ctx, tx, events[0], errors.New("broker unavailable"), &RetryPolicy{Attempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}); err != nil {
+		t.Fatalf("Failed to fail outbox event: %v", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	// Both events of `a` are skipped, then a concurrent courier skips the events claimed by the first one
+	_, first := claimTestOutboxEvents(t, o, 2)
+	if len(first) != 2 || first[0] != ids[2] || first[1] != ids[3] {
+		t.Fatalf("Expected to claim the events of `b` and without key, but got %v", first)
+	}
+
+	tx, second := claimTestOutboxEvents(t, o, 10)
+	if len(second) != 2 || second[0] != ids[4] || second[1] != ids[5] {
+		t.Fatalf("Expected to claim the events of `c`, but got %v", second)
+	}
+
+	heldBack, err := o.heldBackOutboxEvents(ctx, tx, []outboxrepo.FoundationOutboxEvent{{ID: ids[5]}})
+	if err != nil {
+		t.Fatalf("Failed to list held back outbox events: %v", err)
+	}
+	if len(heldBack) != 1 || !heldBack[ids[5]] {
+		t.Errorf("Expected the second event of `c` to be held back behind the first one, but got %v", heldBack)
+	}
+
+	if heldBack, err = o.heldBackOutboxEvents(ctx, tx, []outboxrepo.FoundationOutboxEvent{{ID: ids[4]}, {ID: ids[5]}}); err != nil || len(heldBack) != 0 {
+		t.Errorf("Expected the events of `c` claimed together not to be held back, but got %v, %v", heldBack, err)
+	}
+}
+
+func TestOutboxFailEvent(t *testing.T) {
+	o := newTestOutboxCourier(t)
+	ctx := context.Background()
+	retryPolicy := &RetryPolicy{Attempts: 2, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	ids := createTestOutboxEvents(t, o, "chats", "a")
+
+	fail := func() {
+		t.Helper()
+
+		tx, err := o.GetPostgreSQL().Begin(ctx)
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+		defer tx.Rollback(ctx) // nolint: errcheck
+
+		events, err := o.outboxQueries(tx).ListOutboxEvents(ctx, 1)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("Expected the event to be in the outbox, but got %v, %v", events, err)
+		}
+
+		if err = o.failOutboxEvent(ctx, tx, events[0], errors.New("broker unavailable"), retryPolicy); err != nil {
+			t.Fatalf("Failed to fail outbox event: %v", err)
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			t.Fatalf("Failed to commit transaction: %v", err)
+		}
+	}
+
+	// The event is retried after the backoff
+	fail()
+
+	events, err := o.outboxQueries(o.GetPostgreSQL()).ListOutboxEvents(ctx, 1)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected the event to stay in the outbox, but got %v, %v", events, err)
+	}
+	if events[0].Attempts != 1 || !events[0].LastError.Valid || !events[0].NextAttemptAt.Time.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("Expected 1 attempt and the next one in an hour, but got %+v", events[0])
+	}
+
+	if _, claimed := claimTestOutboxEvents(t, o, 10); len(claimed) != 0 {
+		t.Errorf("Expected the event not to be claimed before its next attempt, but got %v", claimed)
+	}
+
+	// The notified courier wakes up for the next attempt
+	if nextAttemptAt, fErr := o.nextOutboxAttemptAt(ctx); fErr != nil || !nextAttemptAt.Equal(events[0].NextAttemptAt.Time) {
+		t.Errorf("Expected the next attempt at %s, but got %s, %v", events[0].NextAttemptAt.Time, nextAttemptAt, fErr)
+	}
+
+	// Then moved to the dead events once the attempts are exhausted
+	fail()
+
+	dead, err := o.outboxQueries(o.GetPostgreSQL()).GetOutboxDeadEvent(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("Expected the event to be dead, but got %v", err)
+	}
+	if dead.Attempts != 2 || dead.LastError != "broker unavailable" || string(dead.Payload) != "a" {
+		t.Errorf("Unexpected dead event: %+v", dead)
+	}
+
+	if events, err = o.outboxQueries(o.GetPostgreSQL()).ListOutboxEvents(ctx, 1); err != nil || len(events) != 0 {
+		t.Errorf("Expected the event to be removed from the outbox, but got %v, %v", events, err)
+	}
+}
+
+func TestOutboxPurgeAndReplayEvents(t *testing.T) {
+	o := newTestOutboxCourier(t)
+	ctx := context.Background()
+	queries := o.outboxQueries(o.GetPostgreSQL())
+
+	ids := createTestOutboxEvents(t, o, "chats", "a", "b")
+	createTestOutboxEvents(t, o, "clubs", "a")
+
+	tx, err := o.GetPostgreSQL().Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if fErr := o.MarkOutboxEventsPublished(ctx, tx, ids); fErr != nil {
+		t.Fatalf("Failed to mark outbox events as published: %v", fErr)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	// Only the published events matching the filters are replayed
+	rows, err := queries.ReplayOutboxEvents(ctx, outboxrepo.ReplayOutboxEventsParams{Topic: pgtype.Text{String: "chats", Valid: true}, Key: pgtype.Text{String: "b", Valid: true}})
+	if err != nil || rows != 1 {
+		t.Fatalf("Expected 1 event to be replayed, but got %d, %v", rows, err)
+	}
+
+	events, err := queries.ListOutboxEvents(ctx, 10)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events to publish, but got %v, %v", events, err)
+	}
+	if replayed := events[1]; replayed.ID <= ids[1] || replayed.Topic != "chats" || string(replayed.Payload) != "b" {
+		t.Errorf("Expected the event to be replayed as a new one, but got %+v", replayed)
+	}
+
+	// Only the events published before the retention are purged
+	if purged, fErr := o.PurgeOutboxEvents(ctx, time.Now().Add(-time.Minute)); fErr != nil || purged != 0 {
+		t.Errorf("Expected no events to be purged, but got %d, %v", purged, fErr)
+	}
+
+	if purged, fErr := o.PurgeOutboxEvents(ctx, time.Now().Add(time.Minute)); fErr != nil || purged != 2 {
+		t.Errorf("Expected the 2 published events to be purged, but got %d, %v", purged, fErr)
+	}
+
+	if events, err = queries.ListOutboxEvents(ctx, 10); err != nil || len(events) != 2 {
+		t.Errorf("Expected the events to publish to be kept, but got %v, %v", events, err)
+	}
+}
+
+func TestOutboxRequeueDeadEventNotifies(t *testing.T) {
+	o := newTestOutboxCourier(t)
+	o.Config.Outbox.Notify = true
+	ctx := context.Background()
+
+	ids := createTestOutboxEvents(t, o, "chats", "a")
+
+	tx, _ := claimTestOutboxEvents(t, o, 1)
+	event := outboxrepo.FoundationOutboxEvent{ID: ids[0], Topic: "chats"}
+	if err := o.failOutboxEvent(ctx, tx, event, errors.New("broker unavailable"), &RetryPolicy{Attempts: 1}); err != nil {
+		t.Fatalf("Failed to fail outbox event: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	conn, err := o.GetPostgreSQL().Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{o.Config.Outbox.NotifyChannel()}.Sanitize()); err != nil {
+		t.Fatalf("Failed to listen to the outbox notifications: %v", err)
+	}
+
+	if requeued, fErr := o.RequeueOutboxDeadEvent(ctx, ids[0]); fErr != nil || !requeued {
+		t.Fatalf("Expected the dead event to be requeued, but got %v, %v", requeued, fErr)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err = conn.Conn().WaitForNotification(waitCtx); err != nil {
+		t.Errorf("Expected the couriers to be notified, but got %v", err)
+	}
+}
+
+func TestOutboxPartitionedTable(t *testing.T) {
+	o := newTestPartitionedOutboxCourier(t, OutboxPartitioningDaily)
+	ctx := context.Background()
+	pool := o.GetPostgreSQL()
+	now := time.Now()
+
+	var kind string
+	if err := pool.QueryRow(ctx, "SELECT relkind::TEXT FROM pg_class WHERE oid = to_regclass($1)", o.Config.Outbox.Tables().Events).Scan(&kind); err != nil || kind != "p" {
+		t.Fatalf("Expected the outbox events table to be partitioned, but got %q, %v", kind, err)
+	}
+
+	// Migrating again keeps the partitioned table
+	databaseURL := os.Getenv("FOUNDATION_TEST_DATABASE_URL")
+	if err := MigrateFramework(databaseURL, o.Config.Outbox); err != nil {
+		t.Fatalf("Failed to migrate the partitioned outbox table again: %v", err)
+	}
+
+	// The events are claimed, retried, moved to the dead events and purged across the partitions
+	ids := createTestOutboxEvents(t, o, "chats", "a", "b")
+
+	tx, claimed := claimTestOutboxEvents(t, o, 10)
+	if len(claimed) != 2 || claimed[0] != ids[0] || claimed[1] != ids[1] {
+		t.Fatalf("Expected to claim both events, but got %v", claimed)
+	}
+	if err := o.failOutboxEvent(ctx, tx, outboxrepo.FoundationOutboxEvent{ID: ids[0], Topic: "chats"}, errors.New("broker unavailable"), &RetryPolicy{Attempts: 1}); err != nil {
+		t.Fatalf("Failed to fail outbox event: %v", err)
+	}
+	if fErr := o.MarkOutboxEventsPublished(ctx, tx, ids[1:]); fErr != nil {
+		t.Fatalf("Failed to mark outbox events as published: %v", fErr)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	if _, err := o.outboxQueries(pool).GetOutboxDeadEvent(ctx, ids[0]); err != nil {
+		t.Errorf("Expected the failed event to be dead, but got %v", err)
+	}
+
+	if purged, fErr := o.PurgeOutboxEvents(ctx, now.Add(time.Minute)); fErr != nil || purged != 1 {
+		t.Errorf("Expected the published event to be purged, but got %d, %v", purged, fErr)
+	}
+
+	// The partitions ended before the retention are dropped, unless they still contain events to publish
+	past := now.AddDate(0, 0, -10)
+	if err := createOutboxPartitions(ctx, pool, o.Config.Outbox, past); err != nil {
+		t.Fatalf("Failed to create past outbox partitions: %v", err)
+	}
+
+	var pending int64
+	if err := pool.QueryRow(ctx, "INSERT INTO "+o.Config.Outbox.Tables().Events+" (topic, key, payload, headers, created_at) "+
+		"VALUES ('chats', 'c', 'c', '{}', $1) RETURNING id", past).Scan(&pending); err != nil {
+		t.Fatalf("Failed to create past outbox event: %v", err)
+	}
+
+	if dropped, err := dropOutboxPartitions(ctx, pool, o.Config.Outbox, now.AddDate(0, 0, -1)); err != nil || dropped != outboxPartitionsAhead {
+		t.Errorf("Expected the %d empty past partitions to be dropped, but got %d, %v", outboxPartitionsAhead, dropped, err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if fErr := o.MarkOutboxEventsPublished(ctx, tx, []int64{pending}); fErr != nil {
+		t.Fatalf("Failed to mark outbox events as published: %v", fErr)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	if dropped, err := dropOutboxPartitions(ctx, pool, o.Config.Outbox, now.AddDate(0, 0, -1)); err != nil || dropped != 1 {
+		t.Errorf("Expected the published past partition to be dropped, but got %d, %v", dropped, err)
+	}
+
+	// The current partitions are kept
+	var partitions int
+	if err = pool.QueryRow(ctx, "SELECT COUNT(*) FROM pg_inherits WHERE inhparent = $1::TEXT::regclass", o.Config.Outbox.Tables().Events).Scan(&partitions); err != nil || partitions != outboxPartitionsAhead+2 {
+		t.Errorf("Expected %d partitions, including the default one, but got %d, %v", outboxPartitionsAhead+2, partitions, err)
+	}
+}
+
+func TestMigrateFrameworkKeyValueDatabaseURL(t *testing.T) {
+	o := newTestOutboxCourier(t)
+
+	config, err := pgx.ParseConfig(os.Getenv("FOUNDATION_TEST_DATABASE_URL"))
+	if err != nil {
+		t.Fatalf("Failed to parse FOUNDATION_TEST_DATABASE_URL: %v", err)
+	}
+
+	sslmode := "disable"
+	if config.TLSConfig != nil {
+		sslmode = "require"
+	}
+	databaseURL := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		config.Host, config.Port, config.User, config.Password, config.Database, sslmode)
+
+	outbox := &OutboxConfig{
+		Schema:    o.Config.Outbox.Schema + "_kv",
+		Table:     outboxrepo.DefaultEventsTable,
+		DeadTable: outboxrepo.DefaultDeadEventsTable,
+	}
+	t.Cleanup(func() {
+		_, _ = o.GetPostgreSQL().Exec(context.Background(), "DROP SCHEMA IF EXISTS "+outbox.Schema+" CASCADE")
+	})
+
+	if err = MigrateFramework(databaseURL, outbox); err != nil {
+		t.Fatalf("Expected to migrate with the key=value form, but got %v", err)
+	}
+
+	var table *string
+	if err = o.GetPostgreSQL().QueryRow(context.Background(), "SELECT to_regclass($1)::TEXT", outbox.Tables().Events).Scan(&table); err != nil || table == nil {
+		t.Errorf("Expected the outbox events table to be created in %s, but got %v", outbox.Schema, err)
+	}
+}
@@ -0,0 +1,112 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// outboxBacklogTimeout is the timeout of the outbox backlog query run on each scrape.
+const outboxBacklogTimeout = 5 * time.Second
+
+var (
+	outboxEventsWritten = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "foundation",
+		Subsystem: "outbox",
+		Name:      "events_written_total",
+		Help:      "Number of events written to the outbox.",
+	}, []string{"topic"})
+
+	outboxEventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "foundation",
+		Subsystem: "outbox",
+		Name:      "events_published_total",
+		Help:      "Number of outbox events published to Kafka.",
+	}, []string{"topic"})
+
+	outboxEventsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "foundation",
+		Subsystem: "outbox",
+		Name:      "events_failed_total",
+		Help:      "Number of failed attempts to publish outbox events.",
+	}, []string{"topic"})
+
+	outboxEventsDead = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "foundation",
+		Subsystem: "outbox",
+		Name:      "events_dead_total",
+		Help:      "Number of outbox events moved to the dead events after all the retry attempts.",
+	}, []string{"topic"})
+
+	outboxBatchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "foundation",
+		Subsystem: "outbox",
+		Name:      "batch_publish_duration_seconds",
+		Help:      "Time to publish a batch of outbox events, from claiming them to committing.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+var (
+	outboxBacklogDesc = prometheus.NewDesc(
+		"foundation_outbox_backlog_events",
+		"Number of outbox events waiting to be published.",
+		nil, nil,
+	)
+	outboxOldestEventAgeDesc = prometheus.NewDesc(
+		"foundation_outbox_oldest_event_age_seconds",
+		"Age of the oldest outbox event waiting to be published, zero if there is none.",
+		nil, nil,
+	)
+)
+
+// outboxCollector reports the outbox backlog, queried on each scrape so that it keeps growing when the couriers
+// are stalled.
+type outboxCollector struct {
+	s *Service
+}
+
+// registerOutboxCollector registers the collector of the outbox backlog, once per process.
+func (s *Service) registerOutboxCollector() {
+	err := prometheus.Register(&outboxCollector{s: s})
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &alreadyRegistered) {
+		s.Logger.Error("Failed to register outbox metrics", "error", err)
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxBacklogDesc
+	ch <- outboxOldestEventAgeDesc
+}
+
+// Collect implements the prometheus.Collector interface. The backlog is skipped if it can't be queried, instead of
+// failing the whole scrape.
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	pool, fErr := c.s.LookupPostgreSQL()
+	if fErr != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboxBacklogTimeout)
+	defer cancel()
+
+	backlog, err := c.s.outboxQueries(pool).GetOutboxBacklog(ctx)
+	if err != nil {
+		c.s.Logger.Warn("Failed to query the outbox backlog", "error", err)
+		return
+	}
+
+	age := 0.0
+	if backlog.OldestCreatedAt.Valid {
+		age = time.Since(backlog.OldestCreatedAt.Time).Seconds()
+	}
+
+	ch <- prometheus.MustNewConstMetric(outboxBacklogDesc, prometheus.GaugeValue, float64(backlog.Size))
+	ch <- prometheus.MustNewConstMetric(outboxOldestEventAgeDesc, prometheus.GaugeValue, age)
+}
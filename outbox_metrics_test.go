@@ -0,0 +1,17 @@
+package foundation
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOutboxCollectorWithoutDatabase(t *testing.T) {
+	s := &Service{Config: &Config{Outbox: &OutboxConfig{}}, Logger: initLogger("test", nil, new(slog.LevelVar))}
+
+	// The backlog is skipped instead of failing the whole scrape
+	if count := testutil.CollectAndCount(&outboxCollector{s: s}); count != 0 {
+		t.Errorf("Expected no metrics without the database, but got %d", count)
+	}
+}
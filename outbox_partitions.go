@@ -0,0 +1,163 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/foundation-go/foundation/outboxrepo"
+)
+
+const (
+	// OutboxPartitioningDaily partitions the outbox events table by day of `created_at`.
+	OutboxPartitioningDaily = "daily"
+	// OutboxPartitioningMonthly partitions the outbox events table by month of `created_at`.
+	OutboxPartitioningMonthly = "monthly"
+
+	// outboxPartitionsAhead is the number of partitions created ahead of the current one.
+	outboxPartitionsAhead = 3
+)
+
+// outboxPartition is a range partition of the outbox events table by `created_at`.
+type outboxPartition struct {
+	name string
+	from time.Time
+	to   time.Time
+}
+
+// outboxPartitionAt returns the partition containing the given time.
+func outboxPartitionAt(c *OutboxConfig, t time.Time) outboxPartition {
+	t = t.UTC()
+
+	if c.Partitioning == OutboxPartitioningMonthly {
+		from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		return outboxPartition{name: c.Table + "_p" + from.Format("200601"), from: from, to: from.AddDate(0, 1, 0)}
+	}
+
+	from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	return outboxPartition{name: c.Table + "_p" + from.Format("20060102"), from: from, to: from.AddDate(0, 0, 1)}
+}
+
+// parseOutboxPartition returns the partition with the given name, or false for the default partition
+// and the partitions not created by Foundation.
+func parseOutboxPartition(c *OutboxConfig, name string) (outboxPartition, bool) {
+	suffix, ok := strings.CutPrefix(name, c.Table+"_p")
+	if !ok {
+		return outboxPartition{}, false
+	}
+
+	layout := "20060102"
+	if c.Partitioning == OutboxPartitioningMonthly {
+		layout = "200601"
+	}
+
+	t, err := time.ParseInLocation(layout, suffix, time.UTC)
+	if err != nil {
+		return outboxPartition{}, false
+	}
+
+	partition := outboxPartitionAt(c, t)
+
+	return partition, partition.name == name
+}
+
+// createOutboxPartitionedTable creates the outbox events table partitioned by `created_at`, before the migrations
+// would create a regular one. A regular table can't be partitioned afterwards.
+func createOutboxPartitionedTable(ctx context.Context, db outboxrepo.DBTX, c *OutboxConfig) error {
+	table := c.qualify(c.Table)
+
+	var kind string
+	err := db.QueryRow(ctx, "SELECT relkind::TEXT FROM pg_class WHERE oid = to_regclass($1)", table).Scan(&kind)
+	switch {
+	case err == nil && kind != "p":
+		return fmt.Errorf("outbox table `%s` already exists and isn't partitioned", table)
+	case err == nil:
+		return nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	// The columns of the first migration, the next ones are added by the migrations. The primary key
+	// of a partitioned table must include the partition key, and it can't have an identity column
+	// before PostgreSQL 17.
+	_, err = db.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    id BIGSERIAL,
+    topic TEXT NOT NULL,
+    key TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    headers JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at)`, table))
+
+	return err
+}
+
+// createOutboxPartitions creates the partition containing the given time and the next ones, along with the default
+// partition receiving the events outside of them.
+func createOutboxPartitions(ctx context.Context, db outboxrepo.DBTX, c *OutboxConfig, now time.Time) error {
+	table := c.qualify(c.Table)
+
+	partition := outboxPartitionAt(c, now)
+	for i := 0; i <= outboxPartitionsAhead; i++ {
+		if _, err := db.Exec(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			c.qualify(partition.name), table, partition.from.Format(time.RFC3339), partition.to.Format(time.RFC3339))); err != nil {
+			return fmt.Errorf("failed to create outbox partition `%s`: %w", partition.name, err)
+		}
+
+		partition = outboxPartitionAt(c, partition.to)
+	}
+
+	if _, err := db.Exec(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s DEFAULT", c.qualify(c.Table+"_default"), table)); err != nil {
+		return fmt.Errorf("failed to create default outbox partition: %w", err)
+	}
+
+	return nil
+}
+
+// dropOutboxPartitions drops the partitions ended before the given time, unless they still contain events
+// to publish, and returns their number.
+func dropOutboxPartitions(ctx context.Context, db outboxrepo.DBTX, c *OutboxConfig, before time.Time) (int, error) {
+	rows, err := db.Query(ctx, `SELECT child.relname::TEXT FROM pg_inherits
+JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+WHERE pg_inherits.inhparent = $1::TEXT::regclass`, c.qualify(c.Table))
+	if err != nil {
+		return 0, err
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+	for _, name := range names {
+		partition, ok := parseOutboxPartition(c, name)
+		if !ok || partition.to.After(before) {
+			continue
+		}
+
+		var pending bool
+		if err = db.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE published_at IS NULL)", c.qualify(name))).Scan(&pending); err != nil {
+			return dropped, err
+		}
+
+		if pending {
+			continue
+		}
+
+		if _, err = db.Exec(ctx, "DROP TABLE "+c.qualify(name)); err != nil {
+			return dropped, fmt.Errorf("failed to drop outbox partition `%s`: %w", name, err)
+		}
+
+		dropped++
+	}
+
+	return dropped, nil
+}
@@ -0,0 +1,55 @@
+package foundation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutboxPartitions(t *testing.T) {
+	now := time.Date(2024, time.December, 31, 23, 30, 0, 0, time.FixedZone("UTC+1", 3600))
+
+	for _, tc := range []struct {
+		partitioning string
+		name         string
+		from         time.Time
+		to           time.Time
+	}{
+		{OutboxPartitioningDaily, "events_p20241231", time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{OutboxPartitioningMonthly, "events_p202412", time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		c := &OutboxConfig{Table: "events", Partitioning: tc.partitioning}
+
+		partition := outboxPartitionAt(c, now)
+		if partition.name != tc.name || !partition.from.Equal(tc.from) || !partition.to.Equal(tc.to) {
+			t.Errorf("Expected %s partition %s [%s, %s), but got %+v", tc.partitioning, tc.name, tc.from, tc.to, partition)
+		}
+
+		if parsed, ok := parseOutboxPartition(c, tc.name); !ok || parsed != partition {
+			t.Errorf("Expected to parse partition %s, but got %+v", tc.name, parsed)
+		}
+
+		for _, name := range []string{"events_default", "events_p2024", "other_p20241231"} {
+			if _, ok := parseOutboxPartition(c, name); ok {
+				t.Errorf("Expected %s not to be parsed as a %s partition", name, tc.partitioning)
+			}
+		}
+	}
+}
+
+func TestOutboxConfigTables(t *testing.T) {
+	c := &OutboxConfig{Schema: "chats", Table: "outbox", DeadTable: "outbox_dead"}
+
+	tables := c.Tables()
+	if tables.Events != "chats.outbox" || tables.DeadEvents != "chats.outbox_dead" {
+		t.Errorf("Unexpected tables: %+v", tables)
+	}
+
+	query := "DELETE FROM foundation_outbox_dead_events USING foundation_outbox_events"
+	if renamed := tables.Replacer().Replace(query); renamed != "DELETE FROM chats.outbox_dead USING chats.outbox" {
+		t.Errorf("Unexpected renamed query: %s", renamed)
+	}
+
+	if channel := c.NotifyChannel(); channel != "chats.outbox" {
+		t.Errorf("Expected the channel to be named after the events table, but got %s", channel)
+	}
+}
@@ -0,0 +1,69 @@
+package foundation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	fctx "github.com/foundation-go/foundation/context"
+	fkafka "github.com/foundation-go/foundation/kafka"
+)
+
+func TestEventRounds(t *testing.T) {
+	events := []*Event{
+		{Topic: "chats", Key: "1"},
+		{Topic: "chats", Key: "2"},
+		{Topic: "chats", Key: "1"},
+		{Topic: "chats"},
+		{Topic: "clubs", Key: "1"},
+		{Topic: "chats", Key: "1"},
+		{Topic: "chats"},
+	}
+
+	expected := [][]int{{0, 1, 3, 4, 6}, {2}, {5}}
+	if rounds := eventRounds(events); !reflect.DeepEqual(rounds, expected) {
+		t.Errorf("Expected rounds %v, but got %v", expected, rounds)
+	}
+}
+
+func TestAddDefaultHeaders(t *testing.T) {
+	s := &Service{Name: "chats", ModeName: "grpc"}
+
+	ctx := fctx.WithCorrelationID(context.Background(), "request")
+	ctx = fctx.WithCausationID(ctx, "cause")
+
+	occurredAt := time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC)
+	event, fErr := s.addDefaultHeaders(ctx, &Event{Topic: "chats", ProtoName: "chats.MessageSent", CreatedAt: occurredAt})
+	if fErr != nil {
+		t.Fatal(fErr)
+	}
+
+	id, err := uuid.Parse(event.ID)
+	if err != nil || id.Version() != 7 {
+		t.Errorf("Expected a UUIDv7 event ID, but got %q", event.ID)
+	}
+
+	expected := map[string]string{
+		fkafka.HeaderCausationID:   "cause",
+		fkafka.HeaderCorrelationID: "request",
+		fkafka.HeaderEventID:       event.ID,
+		fkafka.HeaderOccurredAt:    "2024-05-01T12:30:00.000000123Z",
+		fkafka.HeaderProducer:      "chats",
+		fkafka.HeaderProducerMode:  "grpc",
+		fkafka.HeaderProtoName:     "chats.MessageSent",
+		fkafka.HeaderSchemaVersion: EventDefaultSchemaVersion,
+	}
+	if !reflect.DeepEqual(event.Headers, expected) {
+		t.Errorf("Expected headers %v, but got %v", expected, event.Headers)
+	}
+
+	received := newEventFromHeaders(event.Topic, event.Key, event.Payload, event.Headers, time.Now())
+	event.Headers = nil
+	received.Headers = nil
+	if !reflect.DeepEqual(received, event) {
+		t.Errorf("Expected the received event to be %+v, but got %+v", event, received)
+	}
+}
@@ -0,0 +1,9 @@
+package outboxrepo
+
+import "embed"
+
+// Migrations contains the migrations of the outbox tables. They are idempotent, so that they can be applied
+// to the databases where they were copied to the service migrations before.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
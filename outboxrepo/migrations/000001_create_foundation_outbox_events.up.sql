@@ -1,4 +1,4 @@
-CREATE TABLE foundation_outbox_events (
+CREATE TABLE IF NOT EXISTS foundation_outbox_events (
     id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
     topic TEXT NOT NULL,
     key TEXT NOT NULL,
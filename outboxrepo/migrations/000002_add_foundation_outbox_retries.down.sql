@@ -0,0 +1,6 @@
+DROP TABLE foundation_outbox_dead_events;
+
+ALTER TABLE foundation_outbox_events
+    DROP COLUMN attempts,
+    DROP COLUMN last_error,
+    DROP COLUMN next_attempt_at;
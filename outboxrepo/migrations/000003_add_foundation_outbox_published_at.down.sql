@@ -0,0 +1,5 @@
+DROP INDEX foundation_outbox_events_published_at_idx;
+DROP INDEX foundation_outbox_events_unpublished_idx;
+
+ALTER TABLE foundation_outbox_events
+    DROP COLUMN published_at;
@@ -0,0 +1,5 @@
+ALTER TABLE foundation_outbox_events
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS foundation_outbox_events_unpublished_idx ON foundation_outbox_events (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS foundation_outbox_events_published_at_idx ON foundation_outbox_events (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX foundation_outbox_events_failed_keys_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS foundation_outbox_events_failed_keys_idx ON foundation_outbox_events (topic, key, id)
+    WHERE attempts > 0 AND published_at IS NULL;
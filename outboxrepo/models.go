@@ -8,11 +8,27 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type FoundationOutboxEvent struct {
+type FoundationOutboxDeadEvent struct {
 	ID        int64
 	Topic     string
 	Key       string
 	Payload   []byte
 	Headers   []byte
 	CreatedAt pgtype.Timestamptz
+	Attempts  int32
+	LastError string
+	DeadAt    pgtype.Timestamptz
+}
+
+type FoundationOutboxEvent struct {
+	ID            int64
+	Topic         string
+	Key           string
+	Payload       []byte
+	Headers       []byte
+	CreatedAt     pgtype.Timestamptz
+	Attempts      int32
+	LastError     pgtype.Text
+	NextAttemptAt pgtype.Timestamptz
+	PublishedAt   pgtype.Timestamptz
 }
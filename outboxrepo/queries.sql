@@ -2,8 +2,89 @@
 INSERT INTO foundation_outbox_events (topic, key, payload, headers, created_at)
 VALUES ($1, $2, $3, $4, NOW());
 
+-- name: NotifyOutboxEvents :exec
+SELECT pg_notify(sqlc.arg(channel)::TEXT, '');
+
 -- name: ListOutboxEvents :many
-SELECT * FROM foundation_outbox_events ORDER BY id ASC LIMIT $1;
+SELECT * FROM foundation_outbox_events WHERE published_at IS NULL ORDER BY id ASC LIMIT $1;
+
+-- name: GetOutboxBacklog :one
+SELECT COUNT(*) AS size, MIN(created_at)::TIMESTAMPTZ AS oldest_created_at
+FROM foundation_outbox_events WHERE published_at IS NULL;
+
+-- name: ClaimOutboxEvents :many
+SELECT * FROM foundation_outbox_events
+WHERE published_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+  AND (key = '' OR NOT EXISTS (
+    SELECT 1 FROM foundation_outbox_events failed
+    WHERE failed.topic = foundation_outbox_events.topic AND failed.key = foundation_outbox_events.key
+      AND failed.id < foundation_outbox_events.id AND failed.attempts > 0 AND failed.published_at IS NULL
+  ))
+ORDER BY id ASC LIMIT $1 FOR UPDATE SKIP LOCKED;
+
+-- name: ListHeldBackOutboxEvents :many
+SELECT claimed.id FROM foundation_outbox_events claimed
+WHERE claimed.id = ANY(sqlc.arg(ids)::BIGINT[]) AND claimed.key <> '' AND EXISTS (
+    SELECT 1 FROM foundation_outbox_events earlier
+    WHERE earlier.topic = claimed.topic AND earlier.key = claimed.key AND earlier.id < claimed.id
+      AND earlier.published_at IS NULL AND NOT earlier.id = ANY(sqlc.arg(ids)::BIGINT[])
+);
+
+-- name: GetOutboxNextAttemptAt :one
+SELECT MIN(next_attempt_at)::TIMESTAMPTZ AS next_attempt_at
+FROM foundation_outbox_events WHERE attempts > 0 AND published_at IS NULL;
 
 -- name: DeleteOutboxEvents :exec
 DELETE FROM foundation_outbox_events WHERE id <= $1;
+
+-- name: DeleteOutboxEventsByIDs :exec
+DELETE FROM foundation_outbox_events WHERE id = ANY(sqlc.arg(ids)::BIGINT[]);
+
+-- name: MarkOutboxEventsPublished :exec
+UPDATE foundation_outbox_events SET published_at = NOW() WHERE id = ANY(sqlc.arg(ids)::BIGINT[]);
+
+-- name: PurgeOutboxEvents :execrows
+DELETE FROM foundation_outbox_events WHERE published_at < sqlc.arg(published_before)::TIMESTAMPTZ;
+
+-- name: ReplayOutboxEvents :execrows
+INSERT INTO foundation_outbox_events (topic, key, payload, headers, created_at)
+SELECT topic, key, payload, headers, created_at FROM foundation_outbox_events
+WHERE published_at IS NOT NULL
+  AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(topic)::TEXT IS NULL OR topic = sqlc.narg(topic))
+  AND (sqlc.narg(key)::TEXT IS NULL OR key = sqlc.narg(key))
+  AND (sqlc.narg(proto_name)::TEXT IS NULL OR headers->>'proto-name' = sqlc.narg(proto_name))
+ORDER BY id ASC;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE foundation_outbox_events
+SET attempts = attempts + 1, last_error = sqlc.arg(last_error)::TEXT, next_attempt_at = sqlc.arg(next_attempt_at)::TIMESTAMPTZ
+WHERE id = sqlc.arg(id);
+
+-- name: MoveOutboxEventToDead :exec
+WITH dead AS (
+    DELETE FROM foundation_outbox_events WHERE foundation_outbox_events.id = sqlc.arg(id)
+    RETURNING id, topic, key, payload, headers, created_at, attempts
+)
+INSERT INTO foundation_outbox_dead_events (id, topic, key, payload, headers, created_at, attempts, last_error, dead_at)
+SELECT dead.id, dead.topic, dead.key, dead.payload, dead.headers, dead.created_at, dead.attempts + 1, sqlc.arg(last_error)::TEXT, NOW()
+FROM dead;
+
+-- name: ListOutboxDeadEvents :many
+SELECT * FROM foundation_outbox_dead_events ORDER BY id ASC LIMIT $1;
+
+-- name: GetOutboxDeadEvent :one
+SELECT * FROM foundation_outbox_dead_events WHERE id = $1;
+
+-- name: RequeueOutboxDeadEvent :execrows
+WITH requeued AS (
+    DELETE FROM foundation_outbox_dead_events WHERE foundation_outbox_dead_events.id = $1
+    RETURNING id, topic, key, payload, headers, created_at
+)
+INSERT INTO foundation_outbox_events (id, topic, key, payload, headers, created_at)
+SELECT requeued.id, requeued.topic, requeued.key, requeued.payload, requeued.headers, requeued.created_at
+FROM requeued;
+
+-- name: DeleteOutboxDeadEvent :execrows
+DELETE FROM foundation_outbox_dead_events WHERE id = $1;
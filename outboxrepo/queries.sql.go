@@ -7,8 +7,52 @@ package outboxrepo
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+SELECT id, topic, key, payload, headers, created_at, attempts, last_error, next_attempt_at, published_at FROM foundation_outbox_events
+WHERE published_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+  AND (key = '' OR NOT EXISTS (
+    SELECT 1 FROM foundation_outbox_events failed
+    WHERE failed.topic = foundation_outbox_events.topic AND failed.key = foundation_outbox_events.key
+      AND failed.id < foundation_outbox_events.id AND failed.attempts > 0 AND failed.published_at IS NULL
+  ))
+ORDER BY id ASC LIMIT $1 FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, limit int32) ([]FoundationOutboxEvent, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FoundationOutboxEvent
+	for rows.Next() {
+		var i FoundationOutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.Key,
+			&i.Payload,
+			&i.Headers,
+			&i.CreatedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createOutboxEvent = `-- name: CreateOutboxEvent :exec
 INSERT INTO foundation_outbox_events (topic, key, payload, headers, created_at)
 VALUES ($1, $2, $3, $4, NOW())
@@ -31,6 +75,18 @@ func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventPa
 	return err
 }
 
+const deleteOutboxDeadEvent = `-- name: DeleteOutboxDeadEvent :execrows
+DELETE FROM foundation_outbox_dead_events WHERE id = $1
+`
+
+func (q *Queries) DeleteOutboxDeadEvent(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOutboxDeadEvent, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteOutboxEvents = `-- name: DeleteOutboxEvents :exec
 DELETE FROM foundation_outbox_events WHERE id <= $1
 `
@@ -40,8 +96,130 @@ func (q *Queries) DeleteOutboxEvents(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteOutboxEventsByIDs = `-- name: DeleteOutboxEventsByIDs :exec
+DELETE FROM foundation_outbox_events WHERE id = ANY($1::BIGINT[])
+`
+
+func (q *Queries) DeleteOutboxEventsByIDs(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, deleteOutboxEventsByIDs, ids)
+	return err
+}
+
+const getOutboxBacklog = `-- name: GetOutboxBacklog :one
+SELECT COUNT(*) AS size, MIN(created_at)::TIMESTAMPTZ AS oldest_created_at
+FROM foundation_outbox_events WHERE published_at IS NULL
+`
+
+type GetOutboxBacklogRow struct {
+	Size            int64
+	OldestCreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetOutboxBacklog(ctx context.Context) (GetOutboxBacklogRow, error) {
+	row := q.db.QueryRow(ctx, getOutboxBacklog)
+	var i GetOutboxBacklogRow
+	err := row.Scan(&i.Size, &i.OldestCreatedAt)
+	return i, err
+}
+
+const getOutboxDeadEvent = `-- name: GetOutboxDeadEvent :one
+SELECT id, topic, key, payload, headers, created_at, attempts, last_error, dead_at FROM foundation_outbox_dead_events WHERE id = $1
+`
+
+func (q *Queries) GetOutboxDeadEvent(ctx context.Context, id int64) (FoundationOutboxDeadEvent, error) {
+	row := q.db.QueryRow(ctx, getOutboxDeadEvent, id)
+	var i FoundationOutboxDeadEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Topic,
+		&i.Key,
+		&i.Payload,
+		&i.Headers,
+		&i.CreatedAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.DeadAt,
+	)
+	return i, err
+}
+
+const getOutboxNextAttemptAt = `-- name: GetOutboxNextAttemptAt :one
+SELECT MIN(next_attempt_at)::TIMESTAMPTZ AS next_attempt_at
+FROM foundation_outbox_events WHERE attempts > 0 AND published_at IS NULL
+`
+
+func (q *Queries) GetOutboxNextAttemptAt(ctx context.Context) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, getOutboxNextAttemptAt)
+	var next_attempt_at pgtype.Timestamptz
+	err := row.Scan(&next_attempt_at)
+	return next_attempt_at, err
+}
+
+const listHeldBackOutboxEvents = `-- name: ListHeldBackOutboxEvents :many
+SELECT claimed.id FROM foundation_outbox_events claimed
+WHERE claimed.id = ANY($1::BIGINT[]) AND claimed.key <> '' AND EXISTS (
+    SELECT 1 FROM foundation_outbox_events earlier
+    WHERE earlier.topic = claimed.topic AND earlier.key = claimed.key AND earlier.id < claimed.id
+      AND earlier.published_at IS NULL AND NOT earlier.id = ANY($1::BIGINT[])
+)
+`
+
+func (q *Queries) ListHeldBackOutboxEvents(ctx context.Context, ids []int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listHeldBackOutboxEvents, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutboxDeadEvents = `-- name: ListOutboxDeadEvents :many
+SELECT id, topic, key, payload, headers, created_at, attempts, last_error, dead_at FROM foundation_outbox_dead_events ORDER BY id ASC LIMIT $1
+`
+
+func (q *Queries) ListOutboxDeadEvents(ctx context.Context, limit int32) ([]FoundationOutboxDeadEvent, error) {
+	rows, err := q.db.Query(ctx, listOutboxDeadEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FoundationOutboxDeadEvent
+	for rows.Next() {
+		var i FoundationOutboxDeadEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.Key,
+			&i.Payload,
+			&i.Headers,
+			&i.CreatedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.DeadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listOutboxEvents = `-- name: ListOutboxEvents :many
-SELECT id, topic, key, payload, headers, created_at FROM foundation_outbox_events ORDER BY id ASC LIMIT $1
+SELECT id, topic, key, payload, headers, created_at, attempts, last_error, next_attempt_at, published_at FROM foundation_outbox_events WHERE published_at IS NULL ORDER BY id ASC LIMIT $1
 `
 
 func (q *Queries) ListOutboxEvents(ctx context.Context, limit int32) ([]FoundationOutboxEvent, error) {
@@ -60,6 +238,10 @@ func (q *Queries) ListOutboxEvents(ctx context.Context, limit int32) ([]Foundati
 			&i.Payload,
 			&i.Headers,
 			&i.CreatedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -70,3 +252,122 @@ func (q *Queries) ListOutboxEvents(ctx context.Context, limit int32) ([]Foundati
 	}
 	return items, nil
 }
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE foundation_outbox_events
+SET attempts = attempts + 1, last_error = $1::TEXT, next_attempt_at = $2::TIMESTAMPTZ
+WHERE id = $3
+`
+
+type MarkOutboxEventFailedParams struct {
+	LastError     string
+	NextAttemptAt pgtype.Timestamptz
+	ID            int64
+}
+
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventFailed, arg.LastError, arg.NextAttemptAt, arg.ID)
+	return err
+}
+
+const markOutboxEventsPublished = `-- name: MarkOutboxEventsPublished :exec
+UPDATE foundation_outbox_events SET published_at = NOW() WHERE id = ANY($1::BIGINT[])
+`
+
+func (q *Queries) MarkOutboxEventsPublished(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventsPublished, ids)
+	return err
+}
+
+const moveOutboxEventToDead = `-- name: MoveOutboxEventToDead :exec
+WITH dead AS (
+    DELETE FROM foundation_outbox_events WHERE foundation_outbox_events.id = $2
+    RETURNING id, topic, key, payload, headers, created_at, attempts
+)
+INSERT INTO foundation_outbox_dead_events (id, topic, key, payload, headers, created_at, attempts, last_error, dead_at)
+SELECT dead.id, dead.topic, dead.key, dead.payload, dead.headers, dead.created_at, dead.attempts + 1, $1::TEXT, NOW()
+FROM dead
+`
+
+type MoveOutboxEventToDeadParams struct {
+	LastError string
+	ID        int64
+}
+
+func (q *Queries) MoveOutboxEventToDead(ctx context.Context, arg MoveOutboxEventToDeadParams) error {
+	_, err := q.db.Exec(ctx, moveOutboxEventToDead, arg.LastError, arg.ID)
+	return err
+}
+
+const notifyOutboxEvents = `-- name: NotifyOutboxEvents :exec
+SELECT pg_notify($1::TEXT, '')
+`
+
+func (q *Queries) NotifyOutboxEvents(ctx context.Context, channel string) error {
+	_, err := q.db.Exec(ctx, notifyOutboxEvents, channel)
+	return err
+}
+
+const purgeOutboxEvents = `-- name: PurgeOutboxEvents :execrows
+DELETE FROM foundation_outbox_events WHERE published_at < $1::TIMESTAMPTZ
+`
+
+func (q *Queries) PurgeOutboxEvents(ctx context.Context, publishedBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeOutboxEvents, publishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const replayOutboxEvents = `-- name: ReplayOutboxEvents :execrows
+INSERT INTO foundation_outbox_events (topic, key, payload, headers, created_at)
+SELECT topic, key, payload, headers, created_at FROM foundation_outbox_events
+WHERE published_at IS NOT NULL
+  AND ($1::TIMESTAMPTZ IS NULL OR created_at >= $1)
+  AND ($2::TIMESTAMPTZ IS NULL OR created_at < $2)
+  AND ($3::TEXT IS NULL OR topic = $3)
+  AND ($4::TEXT IS NULL OR key = $4)
+  AND ($5::TEXT IS NULL OR headers->>'proto-name' = $5)
+ORDER BY id ASC
+`
+
+type ReplayOutboxEventsParams struct {
+	CreatedFrom pgtype.Timestamptz
+	CreatedTo   pgtype.Timestamptz
+	Topic       pgtype.Text
+	Key         pgtype.Text
+	ProtoName   pgtype.Text
+}
+
+func (q *Queries) ReplayOutboxEvents(ctx context.Context, arg ReplayOutboxEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, replayOutboxEvents,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Topic,
+		arg.Key,
+		arg.ProtoName,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const requeueOutboxDeadEvent = `-- name: RequeueOutboxDeadEvent :execrows
+WITH requeued AS (
+    DELETE FROM foundation_outbox_dead_events WHERE foundation_outbox_dead_events.id = $1
+    RETURNING id, topic, key, payload, headers, created_at
+)
+INSERT INTO foundation_outbox_events (id, topic, key, payload, headers, created_at)
+SELECT requeued.id, requeued.topic, requeued.key, requeued.payload, requeued.headers, requeued.created_at
+FROM requeued
+`
+
+func (q *Queries) RequeueOutboxDeadEvent(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueOutboxDeadEvent, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,59 @@
+package outboxrepo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// DefaultEventsTable is the table of the outbox events the queries are generated for.
+	DefaultEventsTable = "foundation_outbox_events"
+	// DefaultDeadEventsTable is the table of the dead outbox events the queries are generated for.
+	DefaultDeadEventsTable = "foundation_outbox_dead_events"
+)
+
+// Tables are the names of the outbox tables, qualified with the schema if needed.
+type Tables struct {
+	Events     string
+	DeadEvents string
+}
+
+// DefaultTables returns the tables the queries are generated for.
+func DefaultTables() Tables {
+	return Tables{Events: DefaultEventsTable, DeadEvents: DefaultDeadEventsTable}
+}
+
+// Replacer returns the replacer renaming the default tables to these ones in the queries and the migrations.
+func (t Tables) Replacer() *strings.Replacer {
+	return strings.NewReplacer(DefaultDeadEventsTable, t.DeadEvents, DefaultEventsTable, t.Events)
+}
+
+// NewWithTables returns the queries running against the given tables instead of the default ones.
+func NewWithTables(db DBTX, tables Tables) *Queries {
+	if tables == DefaultTables() {
+		return New(db)
+	}
+
+	return New(&tablesDBTX{db: db, replacer: tables.Replacer()})
+}
+
+// tablesDBTX renames the tables in the queries before running them.
+type tablesDBTX struct {
+	db       DBTX
+	replacer *strings.Replacer
+}
+
+func (t *tablesDBTX) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.db.Exec(ctx, t.replacer.Replace(sql), args...)
+}
+
+func (t *tablesDBTX) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return t.db.Query(ctx, t.replacer.Replace(sql), args...)
+}
+
+func (t *tablesDBTX) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.db.QueryRow(ctx, t.replacer.Replace(sql), args...)
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"sync"
 
 	"github.com/google/uuid"
 
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -17,20 +18,22 @@ const (
 )
 
 type Component struct {
+	// Connection is the connection pool. It is replaced on `Reconnect`, use `Pool` to access it concurrently.
 	Connection *pgxpool.Pool
 
+	mu          sync.RWMutex
 	databaseURL string
 	poolSize    int
-	logger      *logrus.Entry
+	logger      *slog.Logger
 }
 
 // ComponentOption is an option to `PostgreSQLComponent`.
 type ComponentOption func(*Component)
 
 // WithLogger sets the logger for the PostgreSQL component.
-func WithLogger(logger *logrus.Entry) ComponentOption {
+func WithLogger(logger *slog.Logger) ComponentOption {
 	return func(c *Component) {
-		c.logger = logger.WithField("component", c.Name())
+		c.logger = logger.With("component", c.Name())
 	}
 }
 
@@ -60,43 +63,93 @@ func NewComponent(opts ...ComponentOption) *Component {
 
 // Start implements the Component interface.
 func (c *Component) Start() error {
-	config, err := pgxpool.ParseConfig(c.databaseURL)
+	return c.StartContext(context.Background())
+}
+
+// StartContext implements the ComponentWithStartContext interface.
+func (c *Component) StartContext(ctx context.Context) error {
+	pool, err := c.connect(ctx, c.databaseURL)
 	if err != nil {
 		return err
 	}
 
+	c.mu.Lock()
+	c.Connection = pool
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Component) connect(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
 	config.MaxConns = int32(c.poolSize)
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err = pool.Ping(context.Background()); err != nil {
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// Reconnect replaces the connection pool with a new one connected to the given database URL,
+// e.g. when the credentials are rotated. The previous pool is closed once all its connections
+// are released. The current pool is kept if the new one cannot connect.
+func (c *Component) Reconnect(databaseURL string) error {
+	pool, err := c.connect(context.Background(), databaseURL)
+	if err != nil {
 		return err
 	}
 
+	c.mu.Lock()
+	previous := c.Connection
 	c.Connection = pool
+	c.databaseURL = databaseURL
+	c.mu.Unlock()
+
+	c.logger.Info("Reconnected to PostgreSQL")
+
+	if previous != nil {
+		go previous.Close()
+	}
 
 	return nil
 }
 
+// Pool returns the current connection pool.
+func (c *Component) Pool() *pgxpool.Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.Connection
+}
+
 // Stop implements the Component interface.
 func (c *Component) Stop() error {
 	c.logger.Info("Disconnecting from PostgreSQL...")
 
-	c.Connection.Close()
+	c.Pool().Close()
 
 	return nil
 }
 
 // Health implements the Component interface.
 func (c *Component) Health() error {
-	if c.Connection == nil {
+	pool := c.Pool()
+	if pool == nil {
 		return fmt.Errorf("connection is not initialized")
 	}
 
-	return c.Connection.Ping(context.Background())
+	return pool.Ping(context.Background())
 }
 
 // Name implements the Component interface.
@@ -1,27 +1,32 @@
 package foundation
 
 import (
-	"errors"
-
-	fpg "github.com/foundation-go/foundation/postgresql"
 	"github.com/getsentry/sentry-go"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fpg "github.com/foundation-go/foundation/postgresql"
 )
 
-func (s *Service) GetPostgreSQL() *pgxpool.Pool {
-	component := s.GetComponent(fpg.ComponentName)
-	if component == nil {
-		err := errors.New("PostgreSQL component is not registered")
-		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+// LookupPostgreSQL returns the PostgreSQL connection pool, or an error if the PostgreSQL
+// component is not registered.
+func (s *Service) LookupPostgreSQL() (*pgxpool.Pool, ferr.FoundationError) {
+	pg, err := GetComponentAs[*fpg.Component](s, fpg.ComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get PostgreSQL component")
 	}
 
-	pg, ok := component.(*fpg.Component)
-	if !ok {
-		err := errors.New("PostgreSQL component is not of type *foundation_postgresql.Component")
+	return pg.Pool(), nil
+}
+
+// GetPostgreSQL returns the PostgreSQL connection pool. It terminates the service if the
+// PostgreSQL component is not registered, use `LookupPostgreSQL` to handle this case.
+func (s *Service) GetPostgreSQL() *pgxpool.Pool {
+	pool, err := s.LookupPostgreSQL()
+	if err != nil {
 		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+		logFatal(s.Logger, err.Error())
 	}
 
-	return pg.Connection
+	return pool
 }
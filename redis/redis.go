@@ -3,9 +3,9 @@ package redis
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -16,16 +16,16 @@ type Component struct {
 	Connection *redis.Client
 
 	url    string
-	logger *logrus.Entry
+	logger *slog.Logger
 }
 
 // ComponentOption is an option to `Component`.
 type ComponentOption func(*Component)
 
 // WithLogger sets the logger for the Redis component.
-func WithLogger(logger *logrus.Entry) ComponentOption {
+func WithLogger(logger *slog.Logger) ComponentOption {
 	return func(c *Component) {
-		c.logger = logger.WithField("component", c.Name())
+		c.logger = logger.With("component", c.Name())
 	}
 }
 
@@ -48,14 +48,26 @@ func NewComponent(opts ...ComponentOption) *Component {
 
 // Start implements the Component interface.
 func (c *Component) Start() error {
+	return c.StartContext(context.Background())
+}
+
+// StartContext implements the ComponentWithStartContext interface.
+func (c *Component) StartContext(ctx context.Context) error {
 	opts, err := redis.ParseURL(c.url)
 	if err != nil {
 		return err
 	}
 
-	c.Connection = redis.NewClient(opts)
+	client := redis.NewClient(opts)
 
-	return c.Health()
+	if err = client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return err
+	}
+
+	c.Connection = client
+
+	return nil
 }
 
 // Stop implements the Component interface.
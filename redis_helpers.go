@@ -1,26 +1,31 @@
 package foundation
 
 import (
-	fredis "github.com/foundation-go/foundation/redis"
 	"github.com/getsentry/sentry-go"
-	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
+
+	ferr "github.com/foundation-go/foundation/errors"
+	fredis "github.com/foundation-go/foundation/redis"
 )
 
-func (s *Service) GetRedis() *redis.Client {
-	component := s.GetComponent(fredis.ComponentName)
-	if component == nil {
-		err := errors.New("redis component is not registered")
-		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+// LookupRedis returns the Redis client, or an error if the Redis component is not registered.
+func (s *Service) LookupRedis() (*redis.Client, ferr.FoundationError) {
+	comp, err := GetComponentAs[*fredis.Component](s, fredis.ComponentName)
+	if err != nil {
+		return nil, ferr.NewInternalError(err, "failed to get Redis component")
 	}
 
-	comp, ok := component.(*fredis.Component)
-	if !ok {
-		err := errors.New("redis component is not of type *foundation_redis.Component")
+	return comp.Connection, nil
+}
+
+// GetRedis returns the Redis client. It terminates the service if the Redis component
+// is not registered, use `LookupRedis` to handle this case.
+func (s *Service) GetRedis() *redis.Client {
+	client, err := s.LookupRedis()
+	if err != nil {
 		sentry.CaptureException(err)
-		s.Logger.Fatal(err)
+		logFatal(s.Logger, err.Error())
 	}
 
-	return comp.Connection
+	return client
 }
@@ -0,0 +1,177 @@
+package foundation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SecretSettings are the Foundation settings holding credentials, watched for rotation.
+var SecretSettings = []string{
+	"DATABASE_URL",
+	"KAFKA_SASL_USERNAME",
+	"KAFKA_SASL_PASSWORD",
+	"SENTRY_DSN",
+	"HYDRA_ADMIN_URL",
+	"ADMIN_TOKEN",
+}
+
+// SecretProvider provides the values of secret settings, e.g. from a secrets manager.
+type SecretProvider interface {
+	// GetSecret returns the value of the secret and whether it exists.
+	GetSecret(key string) (string, bool, error)
+}
+
+// FileSecretProvider reads secrets from the files in a directory, named after the settings
+// (e.g. `/run/secrets/DATABASE_URL` or `/run/secrets/database_url`).
+type FileSecretProvider struct {
+	Dir string
+}
+
+// NewFileSecretProvider returns a new FileSecretProvider reading secrets from the given directory.
+func NewFileSecretProvider(dir string) *FileSecretProvider {
+	return &FileSecretProvider{Dir: dir}
+}
+
+// GetSecret implements the SecretProvider interface.
+func (p *FileSecretProvider) GetSecret(key string) (string, bool, error) {
+	for _, name := range []string{key, strings.ToLower(key)} {
+		content, err := os.ReadFile(filepath.Join(p.Dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+
+	return "", false, nil
+}
+
+// WithSecretProvider adds a secret provider consulted when loading the configuration, see `ConfigLoader`.
+func WithSecretProvider(p SecretProvider) InitOption {
+	return func(s *Service) {
+		s.configLoader.AddSecretProvider(p)
+	}
+}
+
+// SecretRotationHandler applies the new value of a rotated secret.
+type SecretRotationHandler func(value string) error
+
+// OnSecretRotation registers a handler called with the new value of the setting when it is rotated,
+// see `SECRETS_ROTATION_INTERVAL`.
+func (s *Service) OnSecretRotation(key string, handler SecretRotationHandler) {
+	root := s.root()
+
+	root.secretRotationMu.Lock()
+	defer root.secretRotationMu.Unlock()
+
+	if root.secretRotationHandlers == nil {
+		root.secretRotationHandlers = make(map[string][]SecretRotationHandler)
+	}
+
+	root.secretRotationHandlers[key] = append(root.secretRotationHandlers[key], handler)
+}
+
+// secretRotationHandlersFor returns the handlers registered for the setting.
+func (s *Service) secretRotationHandlersFor(key string) []SecretRotationHandler {
+	root := s.root()
+
+	root.secretRotationMu.RLock()
+	defer root.secretRotationMu.RUnlock()
+
+	return root.secretRotationHandlers[key]
+}
+
+// startSecretRotation re-reads the secrets periodically and pushes the changed values to the handlers,
+// until `stopSecretRotation` is called. Stopping waits for the rotation in progress, if any.
+func (s *Service) startSecretRotation() {
+	interval := time.Duration(s.Config.Secrets.RotationInterval) * time.Second
+	if interval <= 0 || s.configLoader == nil {
+		return
+	}
+
+	root := s.root()
+
+	keys := append([]string{}, SecretSettings...)
+	root.secretRotationMu.RLock()
+	for key := range root.secretRotationHandlers {
+		keys = append(keys, key)
+	}
+	root.secretRotationMu.RUnlock()
+
+	current := make(map[string]string, len(keys))
+	for _, key := range keys {
+		current[key], _, _ = s.configLoader.resolve(key)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.stopSecretRotation = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, key := range keys {
+					value, _, err := s.configLoader.resolve(key)
+					if err != nil {
+						sentry.CaptureException(err)
+						s.Logger.Error(fmt.Sprintf("Failed to re-read secret `%s`", key), "error", err)
+						continue
+					}
+
+					if value == current[key] {
+						continue
+					}
+
+					if s.rotateSecret(key, value) {
+						current[key] = value
+					}
+				}
+			}
+		}
+	}()
+}
+
+// rotateSecret pushes the new value of the secret to its handlers and returns whether all of them succeeded.
+func (s *Service) rotateSecret(key, value string) bool {
+	log := s.Logger.With("secret", key)
+
+	handlers := s.secretRotationHandlersFor(key)
+	if len(handlers) == 0 {
+		log.Warn("Secret has changed, but no component supports its rotation: restart the service to apply it")
+		return true
+	}
+
+	log.Info("Secret has changed, rotating...")
+
+	ok := true
+	for _, handler := range handlers {
+		if err := handler(value); err != nil {
+			sentry.CaptureException(err)
+			log.Error("Failed to rotate secret", "error", err)
+			ok = false
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,133 @@
+package foundation
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type staticSecretProvider map[string]string
+
+func (p staticSecretProvider) GetSecret(key string) (string, bool, error) {
+	value, ok := p[key]
+	return value, ok, nil
+}
+
+func TestConfigLoaderSecrets(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+
+	secretsDir := t.TempDir()
+	t.Setenv("FOUNDATION_SECRETS_DIR", secretsDir)
+
+	if err := os.WriteFile(filepath.Join(secretsDir, "kafka_sasl_password"), []byte("from-dir\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	databaseURLFile := filepath.Join(t.TempDir(), "database_url")
+	if err := os.WriteFile(databaseURLFile, []byte("postgres://localhost/secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATABASE_URL_FILE", databaseURLFile)
+
+	l := NewConfigLoader()
+	l.AddSecretProvider(staticSecretProvider{"SENTRY_DSN": "https://sentry", "KAFKA_SASL_PASSWORD": "shadowed"})
+
+	config := NewConfigFrom(l)
+
+	if config.Database.URL != "postgres://localhost/secret" {
+		t.Errorf("Expected database URL from the secret file, but got %q", config.Database.URL)
+	}
+
+	if config.Kafka.SASL.Password != "from-dir" {
+		t.Errorf("Expected SASL password from the secrets directory, but got %q", config.Kafka.SASL.Password)
+	}
+
+	if config.Sentry.DSN != "https://sentry" {
+		t.Errorf("Expected Sentry DSN from the secret provider, but got %q", config.Sentry.DSN)
+	}
+
+	// Environment variables take precedence
+	t.Setenv("DATABASE_URL", "postgres://localhost/env")
+	if value := l.String("DATABASE_URL", ""); value != "postgres://localhost/env" {
+		t.Errorf("Expected database URL from the environment, but got %q", value)
+	}
+}
+
+func TestConfigLoaderSecretFileMissing(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+	t.Setenv("DATABASE_URL_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	config := NewConfig()
+
+	var configErr *ConfigError
+	if err := config.Validate(); !errors.As(err, &configErr) {
+		t.Fatalf("Expected a configuration error, but got %v", err)
+	}
+}
+
+func TestRotateSecret(t *testing.T) {
+	s := &Service{
+		Logger: initLogger("test", nil, new(slog.LevelVar)),
+	}
+
+	var rotated []string
+	s.OnSecretRotation("DATABASE_URL", func(value string) error {
+		rotated = append(rotated, value)
+		return nil
+	})
+
+	if !s.rotateSecret("DATABASE_URL", "postgres://localhost/rotated") {
+		t.Error("Expected the rotation to succeed")
+	}
+
+	if len(rotated) != 1 || rotated[0] != "postgres://localhost/rotated" {
+		t.Errorf("Expected the handler to receive the new value, but got %v", rotated)
+	}
+
+	s.OnSecretRotation("DATABASE_URL", func(string) error {
+		return errors.New("connection refused")
+	})
+
+	if s.rotateSecret("DATABASE_URL", "postgres://localhost/broken") {
+		t.Error("Expected the rotation to fail")
+	}
+}
+
+func TestStopSecretRotationWaitsForRotation(t *testing.T) {
+	t.Setenv("FOUNDATION_CONFIG_DIR", t.TempDir())
+	t.Setenv("APP_SECRET", "initial")
+
+	s := &Service{
+		Config:       &Config{Secrets: &SecretsConfig{RotationInterval: 1}},
+		Logger:       initLogger("test", nil, new(slog.LevelVar)),
+		configLoader: NewConfigLoader(),
+	}
+
+	started, finished := make(chan struct{}), make(chan struct{})
+	s.OnSecretRotation("APP_SECRET", func(string) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+
+	s.startSecretRotation()
+	t.Setenv("APP_SECRET", "rotated")
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected the secret to be rotated")
+	}
+
+	s.stopSecretRotation()
+
+	select {
+	case <-finished:
+	default:
+		t.Error("Expected stopping to wait for the rotation in progress")
+	}
+}
@@ -33,6 +33,12 @@ func (c *Component) Health() error {
 	return nil
 }
 
+// Critical implements the ComponentWithCriticality interface. A Sentry outage must not
+// take the service out of rotation.
+func (c *Component) Critical() bool {
+	return false
+}
+
 // Name implements the Component interface.
 func (c *Component) Name() string {
 	return "sentry"
@@ -2,6 +2,7 @@ package foundation
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	ferr "github.com/foundation-go/foundation/errors"
@@ -16,13 +17,25 @@ type SpinWorker struct {
 	*Service
 
 	Options *SpinWorkerOptions
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
 }
 
 // InitSpinWorker initializes a new Foundation service in worker mode.
-func InitSpinWorker(name string) *SpinWorker {
+func InitSpinWorker(name string, opts ...InitOption) *SpinWorker {
 	return &SpinWorker{
-		Init(name),
-		NewSpinWorkerOptions(),
+		Service: Init(name, opts...),
+		Options: NewSpinWorkerOptions(),
+	}
+}
+
+// NewSpinWorker returns a worker mode running on the given service, see `Service.StartModes`.
+func NewSpinWorker(s *Service) *SpinWorker {
+	return &SpinWorker{
+		Service: s.newModeService(),
+		Options: NewSpinWorkerOptions(),
 	}
 }
 
@@ -53,13 +66,20 @@ func NewSpinWorkerOptions() *SpinWorkerOptions {
 
 // Start runs the Foundation worker
 func (sw *SpinWorker) Start(opts *SpinWorkerOptions) {
+	sw.Service.Start(sw.Mode(opts))
+}
+
+// Mode returns the worker mode to run with `Service.StartModes`.
+func (sw *SpinWorker) Mode(opts *SpinWorkerOptions) *StartOptions {
 	sw.Options = opts
+	sw.root().registerWorker(opts.ModeName, sw)
 
-	sw.Service.Start(&StartOptions{
+	return &StartOptions{
 		ModeName:               opts.ModeName,
 		StartComponentsOptions: sw.Options.StartComponentsOptions,
 		ServiceFunc:            sw.ServiceFunc,
-	})
+		service:                sw.Service,
+	}
 }
 
 // ServiceFunc is the default service function for a worker.
@@ -71,6 +91,10 @@ func (sw *SpinWorker) ServiceFunc(ctx context.Context) error {
 			case <-ctx.Done():
 				break Loop
 			default:
+				if !sw.waitResumed(ctx) {
+					break Loop
+				}
+
 				started := time.Now()
 
 				if err := sw.Options.ProcessFunc(ctx); err != nil {
@@ -89,3 +113,53 @@ func (sw *SpinWorker) ServiceFunc(ctx context.Context) error {
 
 	return nil
 }
+
+// Pause stops the worker from processing after the current iteration, until `Resume` is called.
+func (sw *SpinWorker) Pause() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if !sw.paused {
+		sw.paused = true
+		sw.resumed = make(chan struct{})
+		sw.Logger.Info("Worker paused")
+	}
+}
+
+// Resume resumes processing of the paused worker.
+func (sw *SpinWorker) Resume() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.paused {
+		sw.paused = false
+		close(sw.resumed)
+		sw.Logger.Info("Worker resumed")
+	}
+}
+
+// Paused returns whether the worker is paused.
+func (sw *SpinWorker) Paused() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	return sw.paused
+}
+
+// waitResumed blocks while the worker is paused. It returns false if the context is done meanwhile.
+func (sw *SpinWorker) waitResumed(ctx context.Context) bool {
+	sw.mu.Lock()
+	paused, resumed := sw.paused, sw.resumed
+	sw.mu.Unlock()
+
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}